@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/xml"
 	"errors"
 	"fmt"
@@ -11,6 +12,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"codeberg.org/puregotk/puregotk/v4/adw"
 	"codeberg.org/puregotk/puregotk/v4/gio"
@@ -19,9 +21,11 @@ import (
 	"github.com/sewnie/rbxweb"
 	"github.com/sewnie/wine"
 	"github.com/vinegarhq/vinegar/internal/config"
+	"github.com/vinegarhq/vinegar/internal/diagnose"
 	"github.com/vinegarhq/vinegar/internal/dirs"
 	"github.com/vinegarhq/vinegar/internal/gutil"
 	"github.com/vinegarhq/vinegar/internal/logging"
+	"github.com/vinegarhq/vinegar/internal/netutil"
 	"github.com/vinegarhq/vinegar/internal/sysinfo"
 	"golang.org/x/sys/unix"
 
@@ -41,6 +45,27 @@ type app struct {
 
 	mgr  *manager // nullable
 	boot *bootstrapper
+
+	diag *diagnose.Diagnoser
+
+	trace     *os.File // nil until the first Wine trace message
+	traceOnce sync.Once
+
+	// Whether the wineserver was started by this process, and the
+	// seconds it persists for after all Wine processes have exited.
+	ownServer     bool
+	serverTimeout int
+
+	// Semaphore held while packages are written to the downloads
+	// directory, as prefetching and installing a deployment may
+	// download the same packages.
+	downloads chan struct{}
+
+	// Local deployment mirror served on the loopback interface.
+	mirrorMu   sync.Mutex
+	mirrorDir  string
+	mirrorAddr string
+	mirrorStop func() error
 }
 
 func newApp() *app {
@@ -70,6 +95,9 @@ func newApp() *app {
 		),
 		version: data.Releases.Release[0].Version,
 		rbx:     rbxweb.NewClient(),
+		diag:    diagnose.New(diagnose.Rules(), L),
+
+		downloads: make(chan struct{}, 1),
 	}
 
 	startup := a.startup
@@ -92,23 +120,15 @@ func (a *app) applyConfig() {
 	a.pfx.Stderr = io.Writer(a)
 	a.pfx.Stdout = a.pfx.Stderr
 
+	if err := a.cfg.Studio.CheckWrappers(); err != nil {
+		slog.Warn("Studio will fail to launch with the configured wrappers", "err", err)
+	}
+
 	if a.cfg.Debug {
 		a.rbx.Client.Transport = &debugTransport{
 			underlying: http.DefaultTransport,
 		}
 	}
-
-	if string(a.cfg.Studio.WineRoot) == dirs.WinePath {
-		return
-	}
-
-	// User is currently using another wine build
-	path, err := filepath.EvalSymlinks(dirs.WinePath)
-	if err == nil {
-		slog.Info("Removing unused Wine build", "path", path)
-		_ = os.RemoveAll(path)
-		_ = os.RemoveAll(dirs.WinePath)
-	}
 }
 
 func (a *app) startup(_ gio.Application) {
@@ -168,6 +188,29 @@ func (a *app) commandLine(_ gio.Application, clPtr uintptr) int32 {
 		args = args[1:] // skip 'run' cmd
 	}
 
+	if len(args) >= 1 && args[0] == "mirror" {
+		if len(args) == 3 && !filepath.IsAbs(args[2]) {
+			args[2] = filepath.Join(cl.GetCwd(), args[2])
+		}
+		return a.commandThread(cl, func() error {
+			return a.mirrorCommand(commandReporter(cl), args[1:]...)
+		})
+	}
+
+	if len(args) >= 1 && args[0] == "plugin" {
+		return a.commandResult(cl, a.pluginCommand(cl, args[1:]...))
+	}
+
+	if len(args) == 1 && args[0] == "warmup" {
+		return a.commandThread(cl, a.warmup)
+	}
+
+	if len(args) == 1 && args[0] == "prefetch" {
+		return a.commandThread(cl, func() error {
+			return a.prefetch(context.Background(), commandReporter(cl))
+		})
+	}
+
 	// Override arguments to prioritize welcome screen
 	_, err := os.Stat(dirs.Data)
 	if err != nil {
@@ -184,6 +227,9 @@ func (a *app) commandLine(_ gio.Application, clPtr uintptr) int32 {
 		// existing at once
 		if a.mgr == nil {
 			a.mgr = a.newManager()
+			if a.cfg.Studio.Prefetch {
+				a.startPrefetch()
+			}
 		}
 		a.mgr.win.Present()
 
@@ -207,15 +253,65 @@ func (a *app) commandLine(_ gio.Application, clPtr uintptr) int32 {
 	return 0
 }
 
+// commandReporter returns a reporter that shows the progress of
+// transfers to the caller of a command-line only command.
+func commandReporter(cl *gio.ApplicationCommandLine) netutil.Reporter {
+	return netutil.NewTerminalReporter(commandWriter{cl})
+}
+
+// commandWriter implements io.Writer, writing to the standard
+// error of the caller of a command-line.
+type commandWriter struct{ cl *gio.ApplicationCommandLine }
+
+func (w commandWriter) Write(b []byte) (int, error) {
+	w.cl.PrinterrLiteral(string(b))
+	return len(b), nil
+}
+
+// commandThread runs the command-line only command fn in a thread, to
+// not block the main loop, reporting its result to the caller of the
+// command-line once done.
+func (a *app) commandThread(cl *gio.ApplicationCommandLine, fn func() error) int32 {
+	a.Hold()
+	cl.Ref()
+	go func() {
+		err := fn()
+		gutil.IdleAdd(func() {
+			defer a.Release()
+			defer cl.Unref()
+			cl.SetExitStatus(a.commandResult(cl, err))
+			cl.Done()
+		})
+	}()
+	return 0
+}
+
+// commandResult reports the result of a command-line only command
+// to the caller, returning its exit status.
+func (a *app) commandResult(cl *gio.ApplicationCommandLine, err error) int32 {
+	if err != nil {
+		slog.Error("Command failed", "err", err)
+		cl.PrinterrLiteral(err.Error() + "\n")
+		return 1
+	}
+	return 0
+}
+
 func (a *app) shutdown(_ gio.Application) {
 	if err := a.boot.backupSettings(); err != nil {
 		slog.Error("Failed to backup Studio settings", "err", err)
 	}
+	a.boot.stopCompanions()
 
-	// TODO: Wait for studio processes or Wineserver to die
-	if a.boot.count > 1 {
+	switch {
+	case a.boot.count > 1:
 		slog.Warn("Handing off Wineserver control!")
-	} else {
+	case !a.ownServer:
+		slog.Info("Goodbye! Wineserver is owned by another process")
+	case a.serverTimeout != 0:
+		slog.Info("Goodbye! Leaving wineserver persistent",
+			"timeout", a.serverTimeout)
+	default:
 		slog.Info("Goodbye!")
 	}
 }
@@ -259,7 +355,7 @@ func (a *app) showError(e error) {
 	// In a bootstrapper context, the window is destroyed to show the
 	// error instead, which will make the GtkApplication exit.
 	a.Hold()
-	d := adw.NewAlertDialog(L("Something went wrong"), e.Error())
+	d := adw.NewAlertDialog(L("Something went wrong"), e.Error()+a.causes(e.Error()))
 	d.AddResponses("okay", L("Ok"), "open", L("Open Log"))
 	d.SetCloseResponse("okay")
 	d.SetDefaultResponse("okay")
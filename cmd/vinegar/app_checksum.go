@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/vinegarhq/vinegar/internal/netutil"
+)
+
+// verifyCached ensures the named cached file matches the checksum
+// recorded in its '.sha256' sidecar when it was downloaded.
+func verifyCached(name string) error {
+	sum, err := os.ReadFile(name + ".sha256")
+	if err != nil {
+		return err
+	}
+	return netutil.VerifyFile(name, strings.TrimSpace(string(sum)))
+}
+
+// writeCached verifies the named downloaded file against the hex-encoded
+// checksum sum if non-empty, and records its checksum in its '.sha256'
+// sidecar for verifyCached. The file is removed if it does not match.
+func writeCached(name, sum string) error {
+	got, err := netutil.FileSum(name)
+	if err != nil {
+		return err
+	}
+
+	if sum != "" && !strings.EqualFold(got, sum) {
+		_ = os.Remove(name)
+		return fmt.Errorf("%w: got %s, want %s", netutil.ErrChecksum, got, sum)
+	}
+
+	return os.WriteFile(name+".sha256", []byte(got+"\n"), 0o644)
+}
+
+// downloadCached downloads the named url to the named cached file, and
+// records its checksum as in writeCached. As a mismatching checksum may
+// be caused by a corrupted transfer, the download is attempted once more
+// before failing.
+func (b *bootstrapper) downloadCached(ctx context.Context, url, name, sum string) error {
+	for retry := false; ; retry = true {
+		if err := b.download(ctx, url, name); err != nil {
+			return fmt.Errorf("download: %w", err)
+		}
+
+		err := writeCached(name, sum)
+		if !errors.Is(err, netutil.ErrChecksum) || retry {
+			return err
+		}
+		slog.Warn("Downloaded file is corrupt, downloading again", "err", err)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/sewnie/wine"
+	"github.com/vinegarhq/vinegar/internal/diagnose"
+	"github.com/vinegarhq/vinegar/internal/gutil"
+
+	. "github.com/pojntfx/go-gettext/pkg/i18n"
+)
+
+// The messages of the built-in diagnosis rules, which are translated by
+// the diagnoser once matched, are listed here to be extracted for
+// translation alongside the rest of the application's messages.
+var _ = func() []string {
+	return []string{
+		L("Your Wineprefix is corrupt! Please delete all data in Vinegar's settings."),
+		L("No Vulkan driver was found, which the DXVK and Vulkan renderers require. Install the Vulkan driver for your GPU, or set the renderer to D3D11."),
+		L("The graphics driver failed with $1. Update your graphics driver, or set the renderer to D3D11."),
+		L("DXVK could not use your GPU. Select another GPU, or set the renderer to D3D11."),
+		L("Web pages crashed, and logging in may not work. Disable Web Pages in Vinegar's settings if this persists."),
+	}
+}
+
+// diagnose matches the given log line against the known log signatures,
+// logging the diagnosis and notifying the user of errors when a rule
+// is matched for the first time.
+func (a *app) diagnose(src diagnose.Source, line string) *diagnose.Match {
+	m, first := a.diag.Diagnose(src, line)
+	if m == nil || !first || m.Level == diagnose.LevelNoise {
+		return m
+	}
+
+	slog.Warn("Diagnosed log line",
+		"rule", m.Name, "diagnosis", m.Diagnosis, "line", m.Line)
+
+	if m.Level != diagnose.LevelError {
+		return m
+	}
+
+	err := errors.New(m.Message)
+	gutil.IdleAdd(func() {
+		switch m.Action {
+		case "kill-prefix":
+			a.pfx.Server(wine.ServerKill, "9")
+		}
+		a.showError(err)
+	})
+	return m
+}
+
+// causes returns the messages of the diagnosed log lines other
+// than the given message, for showing alongside an error.
+func (a *app) causes(msg string) string {
+	var s strings.Builder
+	for _, m := range a.diag.Matches() {
+		if m.Message == "" || m.Message == msg {
+			continue
+		}
+		s.WriteString("\n• " + m.Message)
+	}
+	if s.Len() == 0 {
+		return ""
+	}
+	return "\n\n" + L("Possible causes:") + s.String()
+}
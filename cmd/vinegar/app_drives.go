@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/vinegarhq/vinegar/internal/config"
+	"github.com/vinegarhq/vinegar/internal/state"
+)
+
+// mapDrives links the configured drives in the Wineprefix's dosdevices,
+// and removes the drives previously mapped by Vinegar that are no
+// longer configured. If the state cannot be loaded, only the configured
+// drives are linked, as those previously mapped are unknown.
+func (a *app) mapDrives() error {
+	dir := filepath.Join(a.pfx.Dir(), "dosdevices")
+
+	s, err := state.Load()
+	if err != nil {
+		slog.Error("Failed to load state, skipping drive bookkeeping", "err", err)
+		s = nil
+	}
+
+	var mapped []string
+	for letter, target := range a.cfg.Studio.Drives {
+		name, err := config.DriveName(letter)
+		if err != nil {
+			return err
+		}
+		mapped = append(mapped, name)
+
+		link := filepath.Join(dir, name)
+		if cur, err := os.Readlink(link); err == nil && cur == target {
+			continue
+		}
+		if err := removeDrive(link); err != nil {
+			return err
+		}
+
+		slog.Info("Mapping drive", "drive", name, "target", target)
+		if err := os.Symlink(target, link); err != nil {
+			return fmt.Errorf("drive %s: %w", name, err)
+		}
+	}
+
+	if s == nil {
+		return nil
+	}
+
+	for _, name := range s.Prefix.Drives {
+		if slices.Contains(mapped, name) {
+			continue
+		}
+		slog.Info("Removing drive", "drive", name)
+		if err := removeDrive(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+
+	slices.Sort(mapped)
+	if slices.Equal(mapped, s.Prefix.Drives) {
+		return nil
+	}
+	return state.Update(func(s *state.State) {
+		s.Prefix.Drives = mapped
+	})
+}
+
+// removeDrive removes the named drive link, refusing to
+// remove a drive that is not a link.
+func removeDrive(link string) error {
+	info, err := os.Lstat(link)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		return fmt.Errorf("drive %s: not a link", filepath.Base(link))
+	}
+	return os.Remove(link)
+}
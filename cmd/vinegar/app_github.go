@@ -0,0 +1,30 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/google/go-github/v80/github"
+	"github.com/vinegarhq/vinegar/internal/dirs"
+	"github.com/vinegarhq/vinegar/internal/netutil"
+)
+
+// githubClient returns a GitHub client whose responses are cached on
+// disk, to not exceed the rate limit of unauthenticated requests, which
+// are the default unless a token is configured or set in GITHUB_TOKEN.
+func (a *app) githubClient() *github.Client {
+	client := github.NewClient(&http.Client{
+		Transport: netutil.NewCacheTransport(filepath.Join(dirs.Cache, "github")),
+	})
+
+	token := a.cfg.GitHubToken
+	if token == "" {
+		token = os.Getenv("GITHUB_TOKEN")
+	}
+	if token != "" {
+		client = client.WithAuthToken(token)
+	}
+
+	return client
+}
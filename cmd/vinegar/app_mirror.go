@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	cp "github.com/otiai10/copy"
+	"github.com/sewnie/rbxbin"
+	"github.com/sewnie/wine/peutil"
+	"github.com/vinegarhq/vinegar/internal/dirs"
+	"github.com/vinegarhq/vinegar/internal/netutil"
+	"github.com/vinegarhq/vinegar/internal/state"
+)
+
+// Files of a deployment that are not packages, but are required by
+// clients to install the deployment from a mirror. The package
+// manifest must be first.
+var mirrorManifests = []string{
+	"rbxPkgManifest.txt",
+	"rbxManifest.txt",
+}
+
+// Directory within a deployment's directory that holds the files
+// required to export it as a mirror, saved when it is installed.
+const deploymentMirrorDir = ".mirror"
+
+// Name of the file of an exported mirror that holds the package
+// directories of its deployment, in JSON.
+const packageDirsFile = "vinegarPackageDirectories.json"
+
+func (a *app) mirrorCommand(r netutil.Reporter, args ...string) error {
+	if len(args) != 2 || args[0] != "export" {
+		return errors.New("usage: vinegar mirror export <dir>")
+	}
+	return a.exportMirror(args[1], r)
+}
+
+// mirrorURL returns the URL of the configured deployment mirror, if any.
+// As rbxbin requests mirrors with the default HTTP client, which does
+// not support file URLs, local mirrors are served on the loopback
+// interface for as long as they remain configured.
+func (a *app) mirrorURL() (string, error) {
+	u := a.cfg.Studio.MirrorURL()
+	dir, local := strings.CutPrefix(u, "file://")
+
+	a.mirrorMu.Lock()
+	defer a.mirrorMu.Unlock()
+
+	if a.mirrorStop != nil && (!local || dir != a.mirrorDir) {
+		_ = a.mirrorStop()
+		a.mirrorStop = nil
+	}
+	if !local {
+		return u, nil
+	}
+	if a.mirrorStop == nil {
+		url, stop, err := netutil.ServeDir(dir)
+		if err != nil {
+			return "", fmt.Errorf("serve mirror: %w", err)
+		}
+		slog.Info("Serving local mirror", "dir", dir, "url", url)
+		a.mirrorDir, a.mirrorAddr, a.mirrorStop = dir, url, stop
+	}
+	return a.mirrorAddr, nil
+}
+
+// saveMirrorFiles saves the manifests and package directories of the
+// deployment being installed from m to its directory, which are required
+// to export it as a mirror without access to m.
+func (b *bootstrapper) saveMirrorFiles(ctx context.Context, m rbxbin.Mirror, pd rbxbin.PackageDirectories) error {
+	dir := filepath.Join(b.dir, deploymentMirrorDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	for _, name := range mirrorManifests {
+		if err := netutil.Download(ctx, m.PackageURL(b.bin, name), filepath.Join(dir, name), nil); err != nil {
+			return fmt.Errorf("manifest %s: %w", name, err)
+		}
+	}
+
+	data, err := json.Marshal(pd)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, packageDirsFile), data, 0o644)
+}
+
+// packageDirectories returns the package directories of the deployment
+// being installed from m. Mirrors exported by Vinegar provide them, as
+// they are otherwise unavailable without access to Roblox.
+func (b *bootstrapper) packageDirectories(ctx context.Context, m rbxbin.Mirror) (rbxbin.PackageDirectories, error) {
+	if b.cfg.Studio.MirrorURL() != "" {
+		data, err := netutil.Body(ctx, m.PackageURL(b.bin, packageDirsFile))
+		if err == nil {
+			var pd rbxbin.PackageDirectories
+			if err := json.Unmarshal([]byte(data), &pd); err != nil {
+				return nil, fmt.Errorf("mirror package dirs: %w", err)
+			}
+			return pd, nil
+		}
+		slog.Debug("Mirror has no package directories", "err", err)
+	}
+	return m.BinaryDirectories(b.bin)
+}
+
+// installedDeployment returns the deployment recorded as installed
+// in the versions directory.
+func (a *app) installedDeployment() (*rbxbin.Deployment, error) {
+	s, err := state.Load()
+	if err != nil {
+		return nil, err
+	}
+	if s.Studio.GUID == "" {
+		return nil, errors.New("no deployment installed")
+	}
+
+	f, err := peutil.Open(filepath.Join(dirs.Versions, s.Studio.GUID, studioExecutable))
+	if err != nil {
+		return nil, fmt.Errorf("deployment %s: %w", s.Studio.GUID, err)
+	}
+	f.Close()
+
+	return &rbxbin.Deployment{
+		Type:    studio,
+		Channel: s.Studio.Channel,
+		GUID:    s.Studio.GUID,
+	}, nil
+}
+
+// parsePackages parses the packages listed in a package manifest, in
+// which each package is listed as its name, checksum, and the size of
+// its archive and of its contents, each on their own line.
+func parsePackages(r io.Reader) ([]rbxbin.Package, error) {
+	s := bufio.NewScanner(r)
+	if !s.Scan() || strings.TrimSpace(s.Text()) != "v0" {
+		return nil, errors.New("unsupported package manifest")
+	}
+
+	var pkgs []rbxbin.Package
+	for {
+		var fields [4]string
+		var n int
+		for n = 0; n < len(fields) && s.Scan(); n++ {
+			fields[n] = strings.TrimSpace(s.Text())
+		}
+		if n == 0 {
+			break
+		}
+		if n < len(fields) {
+			return nil, fmt.Errorf("package %s: truncated manifest", fields[0])
+		}
+
+		zip, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("package %s: %w", fields[0], err)
+		}
+		size, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("package %s: %w", fields[0], err)
+		}
+		pkgs = append(pkgs, rbxbin.Package{
+			Name:     fields[0],
+			Checksum: fields[1],
+			ZipSize:  zip,
+			Size:     size,
+		})
+	}
+	return pkgs, s.Err()
+}
+
+// exportMirror writes the packages and manifests of the installed
+// deployment to dir, in the same layout as the Roblox mirror, for use
+// as the mirror of other installations. Only local files are used, and
+// the packages are copied from the download cache. Copies are reported
+// to r.
+func (a *app) exportMirror(dir string, r netutil.Reporter) error {
+	d, err := a.installedDeployment()
+	if err != nil {
+		return err
+	}
+	slog.Info("Exporting Deployment", "guid", d.GUID, "channel", d.Channel, "dir", dir)
+
+	src := filepath.Join(dirs.Versions, d.GUID, deploymentMirrorDir)
+	f, err := os.Open(filepath.Join(src, mirrorManifests[0]))
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("deployment %s was installed without its manifests, reinstall it to export it", d.GUID)
+	} else if err != nil {
+		return err
+	}
+	pkgs, err := parsePackages(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("%s: %w", mirrorManifests[0], err)
+	}
+
+	// The mirror URL of an empty mirror is the path of a file
+	// relative to the mirror.
+	var m rbxbin.Mirror
+	path := func(name string) string {
+		return filepath.Join(dir, m.PackageURL(d, name))
+	}
+
+	for _, name := range slices.Concat(mirrorManifests, []string{packageDirsFile}) {
+		if err := cp.Copy(filepath.Join(src, name), path(name)); err != nil {
+			return err
+		}
+	}
+
+	var total int64
+	for _, pkg := range pkgs {
+		total += pkg.ZipSize
+	}
+	r.Start(total)
+	p := netutil.Nested(r)
+
+	for _, pkg := range pkgs {
+		dst := path(pkg.Name)
+		// Not installed, and as such never downloaded.
+		if pkg.Name == "RobloxStudioInstaller.exe" {
+			p.Skip(pkg.ZipSize)
+			continue
+		}
+		if err := pkg.Verify(dst); err == nil {
+			p.Skip(pkg.ZipSize)
+			continue
+		}
+
+		cached := filepath.Join(dirs.Downloads, pkg.Checksum)
+		if err := pkg.Verify(cached); err != nil {
+			err = fmt.Errorf("package %s is not cached: %w", pkg.Name, err)
+			r.Error(err)
+			return err
+		}
+		slog.Info("Copying package", "name", pkg.Name)
+		if err := cp.Copy(cached, dst); err != nil {
+			r.Error(err)
+			return err
+		}
+		p.Bytes(pkg.ZipSize)
+	}
+	r.Done()
+
+	// Written last, as clients use it to determine that the
+	// deployment is available.
+	version := filepath.Join(filepath.Dir(path(versionFile)), versionFile)
+	if err := os.WriteFile(version, []byte(d.GUID), 0o644); err != nil {
+		return err
+	}
+
+	slog.Info("Exported Deployment", "guid", d.GUID)
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+
+	"codeberg.org/puregotk/puregotk/v4/gio"
+	"github.com/vinegarhq/vinegar/internal/dirs"
+	"github.com/vinegarhq/vinegar/internal/plugins"
+)
+
+var errPluginUsage = errors.New("usage: vinegar plugin add <file>... | list | remove <name>...")
+
+// syncPluginsFolder installs the plugins in the configured plugins
+// folder that are not installed or have changed, and removes those
+// removed from it.
+func (a *app) syncPluginsFolder() error {
+	src := a.cfg.Studio.PluginsFolder
+	if src == "" {
+		return nil
+	}
+
+	installed, removed, err := plugins.Sync(dirs.Plugins, src)
+	for _, p := range installed {
+		slog.Info("Installed plugin", "name", p.Name, "src", src)
+	}
+	for _, p := range removed {
+		slog.Info("Removed plugin", "name", p.Name, "src", src)
+	}
+	return err
+}
+
+// pluginCommand manages the installed plugins for the caller
+// of the plugin command.
+func (a *app) pluginCommand(cl *gio.ApplicationCommandLine, args ...string) error {
+	if len(args) < 1 {
+		return errPluginUsage
+	}
+
+	switch args[0] {
+	case "list":
+		list, err := plugins.List(dirs.Plugins)
+		if err != nil {
+			return err
+		}
+		for _, p := range list {
+			state := "enabled"
+			if !p.Enabled {
+				state = "disabled"
+			}
+			cl.PrintLiteral(fmt.Sprintf("%s\t%s\n", p.Name, state))
+		}
+	case "add":
+		if len(args) < 2 {
+			return errPluginUsage
+		}
+		for _, name := range args[1:] {
+			if !filepath.IsAbs(name) {
+				name = filepath.Join(cl.GetCwd(), name)
+			}
+			p, err := plugins.Install(dirs.Plugins, name)
+			if err != nil {
+				return err
+			}
+			slog.Info("Installed plugin", "name", p.Name)
+		}
+	case "remove":
+		if len(args) < 2 {
+			return errPluginUsage
+		}
+		for _, name := range args[1:] {
+			p, err := plugins.Find(dirs.Plugins, name)
+			if err != nil {
+				return err
+			}
+			if err := p.Remove(); err != nil {
+				return err
+			}
+			slog.Info("Removed plugin", "name", p.Name)
+		}
+	default:
+		return errPluginUsage
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"codeberg.org/puregotk/puregotk/v4/glib"
+	"github.com/sewnie/wine/peutil"
+	"github.com/vinegarhq/vinegar/internal/dirs"
+	"github.com/vinegarhq/vinegar/internal/netutil"
+)
+
+// Interval in seconds between update checks in the manager.
+const prefetchInterval = 60 * 60
+
+// prefetch downloads the packages of the latest deployment ahead of
+// time, if it is not already installed, so that installing it at
+// launch only requires extraction. The installed deployment is left
+// untouched, as it may currently be running. Downloads are reported
+// to r, if non-nil. Nothing is done if packages are already being
+// downloaded, whether by another prefetch or an installation.
+func (a *app) prefetch(ctx context.Context, r netutil.Reporter) error {
+	select {
+	case a.downloads <- struct{}{}:
+		defer func() { <-a.downloads }()
+	default:
+		slog.Info("Packages are already being downloaded, skipping prefetch")
+		return nil
+	}
+
+	d, err := a.deployment(ctx)
+	if err != nil {
+		return fmt.Errorf("fetch: %w", err)
+	}
+	log := slog.With("guid", d.GUID, "channel", d.Channel)
+
+	f, err := peutil.Open(filepath.Join(dirs.Versions, d.GUID, studioExecutable))
+	if err == nil {
+		f.Close()
+		log.Info("Deployment already installed, nothing to prefetch")
+		return nil
+	}
+
+	if err := os.MkdirAll(dirs.Downloads, 0o755); err != nil {
+		return err
+	}
+
+	m, err := a.mirror()
+	if err != nil {
+		return fmt.Errorf("fetch mirror: %w", err)
+	}
+
+	pkgs, err := m.GetPackages(d)
+	if err != nil {
+		return fmt.Errorf("fetch packages: %w", err)
+	}
+
+	log.Info("Prefetching Deployment", "count", len(pkgs))
+
+	var p netutil.Reporter
+	if r != nil {
+		var total int64
+		for _, pkg := range pkgs {
+			total += pkg.ZipSize
+		}
+		r.Start(total)
+		p = netutil.Nested(r)
+	}
+
+	group, ctx := a.downloadGroup(ctx)
+	for _, pkg := range pkgs {
+		if pkg.Name == "RobloxStudioInstaller.exe" {
+			if p != nil {
+				p.Skip(pkg.ZipSize)
+			}
+			continue
+		}
+		group.Go(func() error {
+			_, err := downloadPackage(ctx, &m, d, &pkg, p)
+			return err
+		})
+	}
+	if err := group.Wait(); err != nil {
+		if r != nil {
+			r.Error(err)
+		}
+		return err
+	}
+	if r != nil {
+		r.Done()
+	}
+
+	log.Info("Prefetched Deployment")
+	return nil
+}
+
+// startPrefetch periodically prefetches the latest deployment in
+// the background, for as long as the application is running.
+func (a *app) startPrefetch() {
+	run := func() {
+		go func() {
+			if err := a.prefetch(context.Background(), nil); err != nil {
+				slog.Error("Failed to prefetch deployment", "err", err)
+			}
+		}()
+	}
+	run()
+
+	var cb glib.SourceFunc = func(uintptr) bool {
+		run()
+		return true
+	}
+	glib.TimeoutAddSeconds(prefetchInterval, &cb, 0)
+}
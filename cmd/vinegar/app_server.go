@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"codeberg.org/puregotk/puregotk/v4/gio"
+	"codeberg.org/puregotk/puregotk/v4/glib"
+	"github.com/adrg/xdg"
+	"github.com/sewnie/wine"
+	"github.com/vinegarhq/vinegar/internal/sysinfo"
+)
+
+// Minimum seconds a wineserver started by warmup persists for, so
+// that it is still running once the user launches Studio.
+const warmupTimeout = 600
+
+// startServer starts the wineserver of the prefix, persisting for the
+// given amount of seconds after all Wine processes have exited, or
+// indefinitely if negative. If zero, the wineserver is instead started
+// by Wine on demand. Either way, the wineserver is owned by Vinegar.
+func (a *app) startServer(timeout int) error {
+	a.ownServer = true
+	a.serverTimeout = timeout
+	if timeout == 0 {
+		return nil
+	}
+
+	v := ""
+	if timeout > 0 {
+		v = strconv.Itoa(timeout)
+	}
+	slog.Info("Starting persistent wineserver", "timeout", timeout)
+	return a.pfx.Server(wine.ServerPersistent, v)
+}
+
+// warmup prepares the Wineprefix and leaves its wineserver running ahead
+// of time, to reduce the time taken for Studio to launch.
+func (a *app) warmup() error {
+	if !a.pfx.Exists() {
+		slog.Info("Wineprefix is not set up, skipping warmup")
+		return nil
+	}
+	if a.pfx.Running() {
+		slog.Info("Wineserver already running, skipping warmup")
+		return nil
+	}
+
+	timeout := a.cfg.Studio.ServerTimeout
+	if timeout >= 0 && timeout < warmupTimeout {
+		timeout = warmupTimeout
+	}
+	if err := a.startServer(timeout); err != nil {
+		return fmt.Errorf("wineserver: %w", err)
+	}
+	if err := a.pfx.Prepare(); err != nil {
+		return fmt.Errorf("prepare: %w", err)
+	}
+
+	slog.Info("Warmed up Wineprefix")
+	return nil
+}
+
+// setAutostart sets whether warmup is ran at login, requested through the
+// Background portal in Flatpak, otherwise with an autostart desktop entry.
+func (a *app) setAutostart(enable bool) error {
+	if sysinfo.Flatpak {
+		return a.requestBackground(enable)
+	}
+
+	name := filepath.Join(xdg.ConfigHome, "autostart", a.GetApplicationId()+".Warmup.desktop")
+	if !enable {
+		if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	slog.Info("Adding warmup autostart entry", "path", name)
+	return os.WriteFile(name, []byte(`[Desktop Entry]
+Type=Application
+Name=Vinegar
+Comment=Prepare Roblox Studio at login
+Exec="`+exe+`" warmup
+NoDisplay=true
+X-GNOME-Autostart-enabled=true
+`), 0o644)
+}
+
+func (a *app) requestBackground(autostart bool) error {
+	if a.bus == nil {
+		return errors.New("no session bus")
+	}
+
+	slog.Info("Requesting background", "autostart", autostart)
+	_, err := a.bus.CallSync("org.freedesktop.portal.Desktop",
+		"/org/freedesktop/portal/desktop",
+		"org.freedesktop.portal.Background",
+		"RequestBackground",
+		glib.NewVariantParsed(fmt.Sprintf(`('', {
+			'reason': <'Prepare Roblox Studio at login'>,
+			'autostart': <%t>,
+			'commandline': <['vinegar', 'warmup']>
+		})`, autostart)),
+		glib.NewVariantType("(o)"),
+		gio.GDbusCallFlagsNoneValue,
+		-1,
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("dbus: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/vinegarhq/vinegar/internal/dirs"
+	"github.com/vinegarhq/vinegar/internal/snapshot"
+	"github.com/vinegarhq/vinegar/internal/state"
+
+	. "github.com/pojntfx/go-gettext/pkg/i18n"
+)
+
+// Directory of the WebView installation in the Wineprefix.
+const webViewDir = "drive_c/Program Files (x86)/Microsoft/EdgeWebView"
+
+// Files of the Wineprefix excluded from snapshots, as they are
+// reinstalled by the setup: WebView, and the Direct3D DLLs that may
+// have been replaced by DXVK. As restoreSnapshot discards the Wine
+// version recorded in the state, the next setup updates the Wineprefix
+// with 'wineboot -u', which recreates the builtin DLLs of Wine before
+// DXVK is installed again if configured.
+var snapshotExclude = []string{
+	webViewDir,
+	"drive_c/Program Files (x86)/Microsoft/EdgeCore",
+	"drive_c/Program Files (x86)/Microsoft/EdgeUpdate",
+	"drive_c/windows/system32/d3d9.dll",
+	"drive_c/windows/system32/d3d10core.dll",
+	"drive_c/windows/system32/d3d11.dll",
+	"drive_c/windows/system32/dxgi.dll",
+	"drive_c/windows/syswow64/d3d9.dll",
+	"drive_c/windows/syswow64/d3d10core.dll",
+	"drive_c/windows/syswow64/d3d11.dll",
+	"drive_c/windows/syswow64/dxgi.dll",
+	"drive_c/users/*/Temp",
+}
+
+// Amount of automatic snapshots kept for each reason.
+const snapshotKeep = 3
+
+var (
+	errPrefixRunning = errors.New("wineprefix is running")
+	errStudioRunning = errors.New("studio is running, close it to restore a snapshot")
+)
+
+// snapshotPrefix archives the Wineprefix for the given reason.
+func (a *app) snapshotPrefix(reason string) (*snapshot.Snapshot, error) {
+	if !a.pfx.Exists() {
+		return nil, nil
+	}
+	if a.pfx.Running() {
+		return nil, errPrefixRunning
+	}
+
+	s, err := state.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("Creating Wineprefix snapshot", "reason", reason)
+	return snapshot.Create(dirs.Snapshots, a.pfx.Dir(),
+		s.Prefix.Wine, reason, snapshotExclude)
+}
+
+// autoSnapshot archives the Wineprefix before an operation that may
+// break it, keeping only the latest few automatic snapshots. Failing
+// to do so does not prevent the operation.
+func (a *app) autoSnapshot(reason string) {
+	a.boot.message(L("Backing up Wineprefix"), "reason", reason)
+	if _, err := a.snapshotPrefix(reason); errors.Is(err, errPrefixRunning) {
+		slog.Info("Skipping snapshot, Wineprefix is running", "reason", reason)
+		return
+	} else if err != nil {
+		slog.Error("Failed to create Wineprefix snapshot", "err", err)
+		return
+	}
+	pruneSnapshots(reason)
+}
+
+func pruneSnapshots(reason string) {
+	if err := snapshot.Prune(dirs.Snapshots, reason, snapshotKeep); err != nil {
+		slog.Warn("Failed to prune snapshots", "err", err)
+	}
+}
+
+// restoreSnapshot replaces the Wineprefix with the given snapshot,
+// stopping the Wineprefix beforehand. The replaced Wineprefix is
+// archived as well, to be able to undo the restore, which must
+// succeed for the restore to take place.
+func (a *app) restoreSnapshot(snap *snapshot.Snapshot) error {
+	if a.boot.count > 0 {
+		return errStudioRunning
+	}
+
+	slog.Info("Restoring Wineprefix snapshot", "time", snap.Time, "wine", snap.Wine)
+	if a.pfx.Running() {
+		if err := a.pfx.Kill(); err != nil {
+			return fmt.Errorf("kill: %w", err)
+		}
+	}
+
+	// Pruned only once restored, as snap may be one to be pruned.
+	if _, err := a.snapshotPrefix("restore"); err != nil {
+		return fmt.Errorf("snapshot: %w", err)
+	}
+	if err := snap.Restore(a.pfx.Dir()); err != nil {
+		return err
+	}
+	pruneSnapshots("restore")
+
+	// Update the Wineprefix on the next setup to restore
+	// the excluded DLLs, regardless of the Wine version. The
+	// mapped drives are kept, to remove those no longer configured.
+	return state.Update(func(s *state.State) {
+		s.Prefix = state.Prefix{Drives: s.Prefix.Drives}
+	})
+}
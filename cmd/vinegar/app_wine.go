@@ -2,44 +2,69 @@ package main
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"codeberg.org/puregotk/puregotk/v4/adw"
+	"codeberg.org/puregotk/puregotk/v4/gio"
 	"github.com/adrg/xdg"
-	"github.com/google/go-github/v80/github"
 	"github.com/sewnie/wine"
+	"github.com/vinegarhq/vinegar/internal/config"
+	"github.com/vinegarhq/vinegar/internal/diagnose"
 	"github.com/vinegarhq/vinegar/internal/dirs"
 	"github.com/vinegarhq/vinegar/internal/gutil"
 	"github.com/vinegarhq/vinegar/internal/logging"
-	"github.com/vinegarhq/vinegar/internal/netutil"
+	"github.com/vinegarhq/vinegar/internal/state"
+	"github.com/vinegarhq/vinegar/internal/wineroot"
 
 	. "github.com/pojntfx/go-gettext/pkg/i18n"
 )
 
 // Reports whether a Wine Prefix was initialized.
-func (a *app) prepareWine() (bool, error) {
+func (a *app) prepareWine(ctx context.Context) (bool, error) {
 	firstRun := !a.pfx.Exists()
 
 	a.boot.message(L("Setting up Wine"), "first-time", firstRun)
 
 	cmd := a.pfx.Wine("")
-	if string(a.cfg.Studio.WineRoot) == dirs.WinePath && cmd.Err != nil {
-		if err := a.updateWine("Latest"); err != nil {
+	if root := a.cfg.Studio.WineRoot; root == dirs.WinePath && cmd.Err != nil {
+		if err := a.updateWine(ctx, "Latest"); err != nil {
 			return false, fmt.Errorf("dl: %w", err)
 		}
+	} else if err := config.ValidateWineRoot(root); err != nil {
+		return false, fmt.Errorf("%s: %w", root, err)
+	}
+	if _, ok := config.ProtonWine(a.cfg.Studio.WineRoot); ok {
+		if err := a.linkProtonPrefix(); err != nil {
+			return false, fmt.Errorf("proton: %w", err)
+		}
 	}
 	if a.pfx.Running() {
+		// A wineserver started by startServer is owned by this process,
+		// whereas one found already running is left to its owner.
 		return false, nil
 	}
 
+	// Initialization of the Wineprefix cannot be interrupted.
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	if err := a.startServer(a.cfg.Studio.ServerTimeout); err != nil {
+		return false, fmt.Errorf("wineserver: %w", err)
+	}
+
 	if err := a.pfx.Prepare(); err != nil {
 		return firstRun, err
 	}
+	if err := a.upgradePrefix(firstRun); err != nil {
+		return firstRun, fmt.Errorf("upgrade: %w", err)
+	}
 	a.updateWineTheme()
 
 	// Do _not_ do this on prefixes that already exist, only new ones,
@@ -72,69 +97,159 @@ func (a *app) prepareWine() (bool, error) {
 	return true, nil
 }
 
-func (a *app) updateWine(needle string) error {
-	client := github.NewClient(nil)
-	ctx := context.Background()
-
-	var release *github.RepositoryRelease
-	var err error
-	if needle == "Latest" {
-		release, _, err = client.Repositories.GetLatestRelease(ctx, "vinegarhq", "kombucha")
-	} else {
-		release, _, err = client.Repositories.GetReleaseByTag(ctx, "vinegarhq", "kombucha", needle)
+// wineVersion returns the version reported by the Wine of the prefix.
+func (a *app) wineVersion() (string, error) {
+	cmd := a.pfx.Wine("--version")
+	if cmd.Err != nil {
+		return "", cmd.Err
 	}
+	cmd.Stdout = nil
+	out, err := cmd.Output()
 	if err != nil {
-		return fmt.Errorf("release: %w", err)
+		return "", err
 	}
+	return strings.TrimSpace(string(out)), nil
+}
 
-	tag := release.GetTagName()
-	dir := filepath.Join(dirs.Data, dirs.TagPrefix+tag)
-
-	log := slog.With("tag", tag, "released", release.PublishedAt.Time)
+// linkProtonPrefix links the Wineprefix as the 'pfx' directory of Proton's
+// compatibility data directory, for Proton to use the same Wineprefix as
+// Wine, keeping the user's data when switching to and from Proton.
+func (a *app) linkProtonPrefix() error {
+	link := filepath.Join(dirs.Proton, "pfx")
+	if cur, err := os.Readlink(link); err == nil && cur == a.pfx.Dir() {
+		return nil
+	}
 
-	if _, err := os.Stat(dirs.WinePath); err == nil {
-		path, err := filepath.EvalSymlinks(dirs.WinePath)
-		if err != nil {
-			return fmt.Errorf("readlink: %w", err)
+	if err := os.MkdirAll(dirs.Proton, 0o755); err != nil {
+		return err
+	}
+	// Only a stale link is removed, never a Wineprefix.
+	if info, err := os.Lstat(link); err == nil {
+		if info.Mode()&os.ModeSymlink == 0 {
+			return fmt.Errorf("%s is not a link", link)
 		}
-		name := filepath.Base(path)
-		if name == filepath.Base(dir) {
-			log.Info("Wine build up to date")
-			return nil
+		if err := os.Remove(link); err != nil {
+			return err
 		}
+	}
 
-		slog.Info("Removing old Wine build", "name", name)
-		if err := os.RemoveAll(path); err != nil {
-			return fmt.Errorf("remove build: %w", err)
+	slog.Info("Linking Wineprefix for Proton", "link", link)
+	return os.Symlink(a.pfx.Dir(), link)
+}
+
+// upgradePrefix updates the Wineprefix if it was last prepared by
+// another version of Wine, and records the version of Wine that
+// prepared it. The user is warned once if Wine was downgraded, as
+// Wine does not support using a Wineprefix of a newer version.
+func (a *app) upgradePrefix(created bool) error {
+	version, err := a.wineVersion()
+	if err != nil {
+		return fmt.Errorf("version: %w", err)
+	}
+
+	// The state is only bookkeeping, and an unreadable state is treated
+	// as one that is empty, updating the Wineprefix to be safe.
+	s, err := state.Load()
+	if err != nil {
+		slog.Error("Failed to load state, assuming Wineprefix is outdated", "err", err)
+		s = new(state.State)
+	}
+	prev := s.Prefix.Wine
+	if prev == version {
+		return nil
+	}
+	downgrade := s.Prefix.Downgrade
+
+	if !created {
+		log := slog.With("previous", prev, "current", version)
+
+		if prev != "" && wineroot.CompareVersions(version, prev) < 0 &&
+			downgrade != version {
+			log.Warn("Wine is older than the Wine that prepared the Wineprefix")
+			downgrade = version
+			n := gio.NewNotification(L("Wine Downgraded"))
+			n.SetBody(fmt.Sprintf(L("%s is older than %s, which last prepared the Wineprefix. If Studio misbehaves, delete all Wine data in Vinegar's settings."), version, prev))
+			a.SendNotification("wine-downgrade", n)
 		}
 
-		if err := os.Remove(dirs.WinePath); err != nil {
-			return fmt.Errorf("remove link: %w", err)
+		a.boot.message(L("Upgrading Wineprefix"), "previous", prev, "current", version)
+		if err := a.pfx.Boot(wine.BootUpdate).Run(); err != nil {
+			return err
 		}
 	}
 
-	if _, err := os.Stat(dir); err == nil {
-		goto install
+	if err := state.Update(func(s *state.State) {
+		s.Prefix.Wine = version
+		s.Prefix.Downgrade = downgrade
+	}); err != nil {
+		slog.Error("Failed to record Wineprefix version", "err", err)
 	}
+	return nil
+}
 
-	if len(release.Assets) != 1 ||
-		release.Assets[0].GetContentType() != "application/x-xz" {
-		return errors.New("expected single .tar.xz release")
+// updateWine installs the Wine build of the release selected by the
+// given ID, as in findWineRelease, and configures it as the wine root.
+func (a *app) updateWine(ctx context.Context, id string) error {
+	rel, err := a.findWineRelease(ctx, id)
+	if err != nil {
+		return err
 	}
+	dir := rel.Dir()
 
-	log.Info("Fetching Wine build")
-	if err := netutil.ExtractURL(
-		release.Assets[0].GetBrowserDownloadURL(), dirs.Data,
-	); err != nil {
-		return err
+	log := slog.With("source", rel.src.Name, "tag", rel.tag)
+	if rel.release != nil {
+		log = log.With("released", rel.release.PublishedAt.Time)
 	}
 
-install:
-	if err := os.Symlink("kombucha-"+tag, dirs.WinePath); err != nil {
-		return fmt.Errorf("create link: %w", err)
+	// Local tarballs are always installed anew, as they
+	// may have been rebuilt in place.
+	local := rel.src.Path != ""
+
+	if currentWine() == filepath.Base(dir) && !local {
+		log.Info("Wine build up to date")
+		return nil
 	}
 
-	log.Info("Updated Wine build configuration")
+	if err := verifyWine(dir); err != nil || local {
+		log.Info("Fetching Wine build")
+		if err := a.fetchWine(ctx, rel, dir); err != nil {
+			return err
+		}
+	}
+
+	name := filepath.Base(dir)
+	if err := state.Update(func(s *state.State) {
+		if !slices.Contains(s.Wine.Builds, name) {
+			s.Wine.Builds = append(s.Wine.Builds, name)
+		}
+		if s.Wine.IDs == nil {
+			s.Wine.IDs = make(map[string]string)
+		}
+		s.Wine.IDs[name] = rel.ID()
+	}); err != nil {
+		return fmt.Errorf("record build: %w", err)
+	}
+
+	log.Info("Installed Wine build")
+
+	return a.useWine(name)
+}
+
+// useWine configures the named Wine build in the data directory
+// as the wine root. Other builds are kept for switching back to.
+func (a *app) useWine(name string) error {
+	if current := currentWine(); current != "" && current != name {
+		a.autoSnapshot("wine-update")
+	}
+
+	if err := linkWine(name); err != nil {
+		return err
+	}
+
+	slog.Info("Updated Wine build configuration", "name", name)
+	if a.mgr != nil {
+		gutil.IdleAdd(a.mgr.loadWineBuilds)
+	}
 
 	// No need to save wineroot is already set
 	if a.cfg.Studio.WineRoot == dirs.WinePath {
@@ -158,6 +273,100 @@ install:
 	return nil
 }
 
+// wineBuilds returns the names of the Wine builds installed by Vinegar
+// in the data directory, which are those recorded in the state s, and
+// the Kombucha builds installed before they were recorded.
+func wineBuilds(s *state.State) ([]string, error) {
+	entries, err := os.ReadDir(dirs.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	var builds []string
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() || (!slices.Contains(s.Wine.Builds, name) &&
+			!strings.HasPrefix(name, config.KombuchaSource.Name+"-")) {
+			continue
+		}
+		if verifyWine(filepath.Join(dirs.Data, name)) == nil {
+			builds = append(builds, name)
+		}
+	}
+	return builds, nil
+}
+
+// currentWine returns the name of the Wine build currently
+// configured, or an empty string if there is none.
+func currentWine() string {
+	path, err := filepath.EvalSymlinks(dirs.WinePath)
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(path)
+}
+
+// markWineGood records the current Wine build as the last one known
+// to run Studio, if the managed Wine build is in use.
+func (a *app) markWineGood() {
+	if a.cfg.Studio.WineRoot != dirs.WinePath {
+		return
+	}
+	name := currentWine()
+	if name == "" {
+		return
+	}
+
+	if err := state.Update(func(s *state.State) {
+		s.Wine.Good = name
+	}); err != nil {
+		slog.Error("Failed to record working Wine build", "err", err)
+	}
+}
+
+// collectWine removes all installed Wine builds other than the
+// current and the last known working build.
+func (a *app) collectWine() error {
+	s, err := state.Load()
+	if err != nil {
+		return err
+	}
+
+	builds, err := wineBuilds(s)
+	if err != nil {
+		return err
+	}
+
+	current := currentWine()
+	var removed []string
+	defer func() {
+		if err := state.Update(func(s *state.State) {
+			s.Wine.Builds = slices.DeleteFunc(s.Wine.Builds, func(name string) bool {
+				return slices.Contains(removed, name)
+			})
+			for _, name := range removed {
+				delete(s.Wine.IDs, name)
+			}
+		}); err != nil {
+			slog.Error("Failed to record removed Wine builds", "err", err)
+		}
+	}()
+
+	for _, name := range builds {
+		if name == current || name == s.Wine.Good {
+			continue
+		}
+
+		slog.Info("Removing unused Wine build", "name", name)
+		if err := os.RemoveAll(filepath.Join(dirs.Data, name)); err != nil {
+			return fmt.Errorf("remove build: %w", err)
+		}
+		removed = append(removed, name)
+	}
+
+	return nil
+}
+
 // Implements io.Writer for reading the log from Wine
 func (a *app) Write(b []byte) (int, error) {
 	for line := range strings.SplitSeq(string(b[:len(b)-1]), "\n") {
@@ -176,18 +385,39 @@ func (a *app) Write(b []byte) (int, error) {
 			continue
 		}
 
+		// XXXX:trace:channel:function ...
+		if strings.Contains(line, ":trace:") {
+			a.writeTrace(line)
+			continue
+		}
+
 		a.handleWineLog(line)
 	}
 	return len(b), nil
 }
 
+// writeTrace writes the given Wine trace message to the trace log,
+// opened on the first trace message.
+func (a *app) writeTrace(line string) {
+	a.traceOnce.Do(func() {
+		f, err := logging.OpenTrace()
+		if err != nil {
+			slog.Error("Failed to open Wine trace log", "err", err)
+			return
+		}
+		slog.Info("Logging Wine traces to file", "path", logging.TracePath)
+		a.trace = f
+	})
+	if a.trace != nil {
+		fmt.Fprintln(a.trace, line)
+	}
+}
+
 func (a *app) handleWineLog(line string) {
-	if strings.Contains(line, "to unimplemented function advapi32.dll.SystemFunction036") {
-		err := errors.New("Your Wineprefix is corrupt! Please delete all data in Vinegar's settings.")
-		gutil.IdleAdd(func() {
-			a.pfx.Server(wine.ServerKill, "9")
-			a.showError(err)
-		})
+	m := a.diagnose(diagnose.SourceWine, line)
+	if m != nil && m.Level == diagnose.LevelNoise {
+		slog.Debug(line)
+		return
 	}
 
 	slog.Log(context.Background(), logging.LevelWine.Level(), line)
@@ -307,3 +537,91 @@ var lightThemeValues = []wine.RegistryValue{
 	{"WindowFrame", "158 158 158"},
 	{"WindowText", "0 0 0"},
 }
+
+// Age after which the temporary directory of a Wine build
+// extraction is considered to be abandoned.
+const staleWineAge = 24 * time.Hour
+
+// fetchWine extracts the tarball of the given release into dir,
+// verifying it against its checksum. The tarball is extracted into
+// a temporary directory first, and only moved to dir once verified,
+// to never leave a partially extracted build behind.
+func (a *app) fetchWine(ctx context.Context, rel *wineRelease, dir string) error {
+	// Left behind by updates interrupted by the process exiting. Recent
+	// directories may belong to an update still in progress elsewhere.
+	stale, _ := filepath.Glob(filepath.Join(dirs.Data, ".kombucha-*"))
+	for _, name := range stale {
+		if info, err := os.Stat(name); err == nil &&
+			time.Since(info.ModTime()) > staleWineAge {
+			_ = os.RemoveAll(name)
+		}
+	}
+
+	tmp, err := os.MkdirTemp(dirs.Data, ".kombucha-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := rel.extract(ctx, tmp, a.boot.reporter()); err != nil {
+		return err
+	}
+
+	// Tarballs usually contain the build in a single top-level directory.
+	build := tmp
+	if entries, err := os.ReadDir(tmp); err == nil &&
+		len(entries) == 1 && entries[0].IsDir() {
+		build = filepath.Join(tmp, entries[0].Name())
+	}
+	if err := verifyWine(build); err != nil {
+		return err
+	}
+
+	// A build at dir failed verification and is unusable.
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+
+	return os.Rename(build, dir)
+}
+
+// verifyWine ensures the Wine build at dir is complete.
+func verifyWine(dir string) error {
+	info, err := os.Stat(filepath.Join(dir, "bin", "wine"))
+	if err != nil {
+		return fmt.Errorf("verify build: %w", err)
+	}
+	if info.Mode()&0o111 == 0 {
+		return fmt.Errorf("verify build: %s is not executable", info.Name())
+	}
+	return nil
+}
+
+// linkWine atomically points the Wine build symlink to the named
+// build in the data directory.
+func linkWine(name string) error {
+	// Older versions extracted the build in place of the symlink,
+	// which is kept as a build of its own.
+	if info, err := os.Lstat(dirs.WinePath); err == nil && info.IsDir() {
+		legacy := filepath.Join(dirs.Data, "kombucha-legacy")
+		slog.Info("Migrating Wine build", "dir", legacy)
+		if err := os.RemoveAll(legacy); err != nil {
+			return fmt.Errorf("migrate build: %w", err)
+		}
+		if err := os.Rename(dirs.WinePath, legacy); err != nil {
+			return fmt.Errorf("migrate build: %w", err)
+		}
+	}
+
+	tmp := dirs.WinePath + ".new"
+	_ = os.Remove(tmp)
+
+	if err := os.Symlink(name, tmp); err != nil {
+		return fmt.Errorf("create link: %w", err)
+	}
+	if err := os.Rename(tmp, dirs.WinePath); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("replace link: %w", err)
+	}
+	return nil
+}
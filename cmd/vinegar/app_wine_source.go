@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v80/github"
+	"github.com/vinegarhq/vinegar/internal/config"
+	"github.com/vinegarhq/vinegar/internal/dirs"
+	"github.com/vinegarhq/vinegar/internal/netutil"
+	"github.com/vinegarhq/vinegar/internal/state"
+)
+
+// wineRelease is an installable Wine build of a Wine source.
+type wineRelease struct {
+	src config.WineSource
+	tag string
+
+	// Only set for GitHub sources.
+	release *github.RepositoryRelease
+	asset   *github.ReleaseAsset
+}
+
+// ID returns the name the release is selected by, which is the
+// tag of the release for the Kombucha source for compatibility.
+func (r *wineRelease) ID() string {
+	if r.src.Name == config.KombuchaSource.Name {
+		return r.tag
+	}
+	return r.src.Name + "/" + r.tag
+}
+
+// Dir returns the directory the release is installed in.
+func (r *wineRelease) Dir() string {
+	return filepath.Join(dirs.Data, r.src.Name+"-"+r.tag)
+}
+
+// extract extracts the compressed tarball of the release into dir,
+// verifying it against its checksum if known. As a mismatching checksum
+// may be caused by a corrupted transfer, the tarball is downloaded and
+// extracted once more before failing.
+func (r *wineRelease) extract(ctx context.Context, dir string, rep netutil.Reporter) error {
+	if r.src.Path != "" {
+		return netutil.ExtractFile(ctx, r.src.Path, dir)
+	}
+
+	url, sum := r.src.URL, r.src.Sha256
+	if r.asset != nil {
+		var err error
+		url = r.asset.GetBrowserDownloadURL()
+		sum, err = netutil.AssetSum(ctx, r.release, r.asset)
+		if err != nil {
+			return fmt.Errorf("checksum: %w", err)
+		}
+	}
+
+	err := netutil.ExtractURL(ctx, url, dir, sum, rep)
+	if !errors.Is(err, netutil.ErrChecksum) {
+		return err
+	}
+	slog.Warn("Wine build is corrupt, downloading again", "err", err)
+
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		return err
+	}
+	return netutil.ExtractURL(ctx, url, dir, sum, rep)
+}
+
+// wineReleases returns the releases of the given Wine source.
+func (a *app) wineReleases(ctx context.Context, src config.WineSource) ([]wineRelease, error) {
+	if src.Repo == "" {
+		name := src.URL
+		if src.Path != "" {
+			name = src.Path
+		}
+		return []wineRelease{{src: src, tag: archiveName(name)}}, nil
+	}
+
+	owner, repo, _ := strings.Cut(src.Repo, "/")
+	releases, _, err := a.githubClient().Repositories.ListReleases(ctx, owner, repo, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", src.Name, err)
+	}
+
+	var rels []wineRelease
+	for _, release := range releases {
+		rel, err := newWineRelease(src, release)
+		if err != nil {
+			continue
+		}
+		rels = append(rels, *rel)
+	}
+	return rels, nil
+}
+
+// findWineRelease returns the release selected by the given ID, in
+// the form of either "<source>/<tag>" or the tag of a Kombucha release.
+// Latest selects the latest release of the Kombucha source.
+func (a *app) findWineRelease(ctx context.Context, id string) (*wineRelease, error) {
+	name, tag, ok := strings.Cut(id, "/")
+	if !ok {
+		name, tag = config.KombuchaSource.Name, id
+	}
+
+	for _, src := range a.cfg.Studio.Sources() {
+		if src.Name != name {
+			continue
+		}
+
+		if src.Repo == "" {
+			rels, err := a.wineReleases(ctx, src)
+			if err != nil {
+				return nil, err
+			}
+			if rels[0].tag != tag {
+				return nil, fmt.Errorf("%s has no release %s", name, tag)
+			}
+			return &rels[0], nil
+		}
+
+		owner, repo, _ := strings.Cut(src.Repo, "/")
+		client := a.githubClient()
+		var release *github.RepositoryRelease
+		var err error
+		if tag == "Latest" {
+			release, _, err = client.Repositories.GetLatestRelease(ctx, owner, repo)
+		} else {
+			release, _, err = client.Repositories.GetReleaseByTag(ctx, owner, repo, tag)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("release: %w", err)
+		}
+		return newWineRelease(src, release)
+	}
+
+	return nil, fmt.Errorf("unknown wine source: %s", name)
+}
+
+// Extensions of the files published alongside release assets.
+var sidecarExts = []string{".sha256", ".sha512", ".sha1", ".md5", ".sig", ".asc"}
+
+func newWineRelease(src config.WineSource, release *github.RepositoryRelease) (*wineRelease, error) {
+	pattern := src.Asset
+	if pattern == "" {
+		pattern = "*.tar.*"
+	}
+
+	for _, asset := range release.Assets {
+		name := asset.GetName()
+		// Checksums and signatures are published alongside tarballs,
+		// and would otherwise match patterns such as the default.
+		if slices.Contains(sidecarExts, path.Ext(name)) {
+			continue
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return &wineRelease{
+				src:     src,
+				tag:     release.GetTagName(),
+				release: release,
+				asset:   asset,
+			}, nil
+		}
+	}
+
+	return nil, errors.New("release has no matching tarball asset")
+}
+
+// wineBuildID returns the ID of the release installed in the named
+// build directory as recorded in the state, or the name if unknown.
+// Build directories cannot be parsed instead, as the name of a
+// source may be a prefix of the name of another.
+func wineBuildID(s *state.State, name string) string {
+	if id, ok := s.Wine.IDs[name]; ok {
+		return id
+	}
+	return name
+}
+
+// archiveName returns the base name of the named compressed
+// tarball, without its extensions.
+func archiveName(name string) string {
+	name = path.Base(name)
+	if i := strings.Index(name, ".tar"); i > 0 {
+		return name[:i]
+	}
+	return strings.TrimSuffix(name, path.Ext(name))
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
@@ -14,10 +15,14 @@ import (
 	"codeberg.org/puregotk/puregotk/v4/gtk"
 	"github.com/sewnie/rbxbin"
 	"github.com/sewnie/wine"
+	"github.com/vinegarhq/vinegar/internal/diagnose"
 	"github.com/vinegarhq/vinegar/internal/dirs"
 	"github.com/vinegarhq/vinegar/internal/gutil"
 	"github.com/vinegarhq/vinegar/internal/logging"
+	"github.com/vinegarhq/vinegar/internal/netutil"
 	"github.com/vinegarhq/vinegar/internal/studiorpc"
+
+	. "github.com/pojntfx/go-gettext/pkg/i18n"
 )
 
 var (
@@ -29,9 +34,13 @@ type bootstrapper struct {
 	*app
 	win adw.Window
 
-	pbar   gtk.ProgressBar
-	status gtk.Label
-	info   gtk.Label
+	pbar         gtk.ProgressBar
+	status       gtk.Label
+	info         gtk.Label
+	cancelButton gtk.Button
+
+	// cancels the setup, nil if setup is not running
+	cancel context.CancelFunc
 
 	dir string
 	bin *rbxbin.Deployment
@@ -39,6 +48,19 @@ type bootstrapper struct {
 	// amount of Roblox processes that are open
 	count uint
 
+	// latest deployment announced while Studio is running, and
+	// whether the user accepted to update to it.
+	updateMu sync.Mutex
+	latest   string
+	pending  bool
+
+	// source of the periodic update check, 0 if not running
+	updates uint32
+
+	// companions running alongside Studio, nil if not running
+	companions   *companions
+	companionsMu sync.Mutex
+
 	rp *studiorpc.StudioRPC
 }
 
@@ -53,15 +75,24 @@ func (a *app) newBootstrapper() *bootstrapper {
 
 	builder.GetObject("window").Cast(&b.win)
 	destroy := func(_ gtk.Window) bool {
-		// TODO: context cancellation
+		// The window is hidden by the setup itself once it has
+		// stopped, to leave the installation in a usable state.
+		if b.cancel != nil {
+			b.cancelButton.SetSensitive(false)
+			b.status.SetLabel(L("Cancelling"))
+			b.cancel()
+			return true
+		}
 		b.Quit()
 		return false
 	}
 	b.win.ConnectCloseRequest(&destroy)
+	b.connectUpdate()
 
 	builder.GetObject("status").Cast(&b.status)
 	builder.GetObject("progress").Cast(&b.pbar)
 	builder.GetObject("info").Cast(&b.info)
+	builder.GetObject("cancel").Cast(&b.cancelButton)
 	b.status.Unref()
 	b.pbar.Unref()
 
@@ -74,7 +105,12 @@ func (b *bootstrapper) run(args ...string) error {
 		return nil
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+	b.cancel = cancel
+	defer cancel()
+
 	gutil.IdleAdd(func() {
+		b.cancelButton.SetSensitive(true)
 		b.app.AddWindow(&b.win.Window)
 		b.win.Present()
 	})
@@ -83,11 +119,30 @@ func (b *bootstrapper) run(args ...string) error {
 		b.win.SetVisible(false) // Incase bailed out
 	})
 
-	if err := b.setupExecute(); err != nil {
+	err := b.setupExecute(ctx)
+	b.cancel = nil
+	if errors.Is(err, context.Canceled) {
+		slog.Warn("Setup cancelled!")
+		return nil
+	} else if err != nil {
 		return fmt.Errorf("setup: %w", err)
 	}
 
-	return b.execute(args...)
+	err = b.execute(args...)
+	if err == nil {
+		b.markWineGood()
+	}
+
+	// An accepted update is installed regardless of how Studio exited.
+	if b.relaunch() {
+		if err != nil {
+			slog.Error("Studio exited with an error", "err", err)
+		}
+		slog.Info("Relaunching Studio for update")
+		return b.run()
+	}
+
+	return err
 }
 
 func (b *bootstrapper) restoreSettings() error {
@@ -146,6 +201,8 @@ func (b *bootstrapper) backupSettings() error {
 }
 
 func (b *bootstrapper) handleRobloxLog(line string) {
+	b.diagnose(diagnose.SourceRoblox, line)
+
 	switch {
 	case strings.Contains(line, "LoginDialog Error: Embedded Web Browser fail to load"):
 		// Ensure that browser login functionality will work
@@ -211,6 +268,12 @@ func (b *bootstrapper) performing() func() {
 	return sync.OnceFunc(func() { glib.SourceRemove(id) })
 }
 
+// download downloads the named url to the named file, while
+// showing its progress.
+func (b *bootstrapper) download(ctx context.Context, url, file string) error {
+	return netutil.DownloadProgress(ctx, url, file, b.reporter())
+}
+
 func (b *bootstrapper) message(msg string, args ...any) {
 	slog.Info(msg, args...)
 	gutil.IdleAdd(func() { b.status.SetLabel(msg) })
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/vinegarhq/vinegar/internal/config"
+	"github.com/vinegarhq/vinegar/internal/logging"
+	"github.com/vinegarhq/vinegar/internal/sysinfo"
+)
+
+const (
+	// Delay before restarting a crashed companion, doubled on
+	// each crash up to the maximum delay.
+	companionBackoff    = time.Second
+	companionMaxBackoff = time.Minute
+	// Duration a companion must run for to reset its delay.
+	companionStable = time.Minute
+)
+
+// companions is a group of running companions.
+type companions struct {
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// startCompanions starts the configured companions,
+// if they are not already running.
+func (b *bootstrapper) startCompanions() {
+	b.companionsMu.Lock()
+	defer b.companionsMu.Unlock()
+	if b.companions != nil || len(b.cfg.Studio.Companions) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cs := &companions{cancel: cancel}
+	for _, c := range b.cfg.Studio.Companions {
+		cs.wg.Go(func() {
+			runCompanion(ctx, c)
+		})
+	}
+	b.companions = cs
+}
+
+// stopCompanions stops the running companions and waits for them to exit.
+func (b *bootstrapper) stopCompanions() {
+	b.companionsMu.Lock()
+	defer b.companionsMu.Unlock()
+	if b.companions == nil {
+		return
+	}
+
+	slog.Info("Stopping companions")
+	b.companions.cancel()
+	b.companions.wg.Wait()
+	b.companions = nil
+}
+
+// runCompanion runs the companion until the context is done, restarting
+// it with an increasing delay if it fails.
+func runCompanion(ctx context.Context, c config.Companion) {
+	if c.Name == "" {
+		c.Name = filepath.Base(c.Command)
+	}
+
+	backoff := companionBackoff
+	for {
+		start := time.Now()
+		err := startCompanion(ctx, &c)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			slog.Info("Companion exited", "name", c.Name)
+			return
+		}
+
+		if time.Since(start) >= companionStable {
+			backoff = companionBackoff
+		}
+		slog.Error("Companion failed, restarting", "name", c.Name, "err", err, "delay", backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, companionMaxBackoff)
+	}
+}
+
+func startCompanion(ctx context.Context, c *config.Companion) error {
+	path, host, err := config.LookPath(c.Command)
+	if err != nil {
+		return err
+	}
+
+	var env []string
+	for k, v := range c.Env {
+		env = append(env, k+"="+v)
+	}
+	args := append([]string{path}, c.Args...)
+	if host {
+		spawn := sysinfo.HostCommand(env)
+		if c.Dir != "" {
+			spawn = append(spawn, "--directory="+c.Dir)
+		}
+		args = append(spawn, args...)
+	}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = c.Dir
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = 5 * time.Second
+
+	r, w := io.Pipe()
+	cmd.Stdout = w
+	cmd.Stderr = w
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s := bufio.NewScanner(r)
+		for s.Scan() {
+			slog.Log(context.Background(), logging.LevelCompanion.Level(),
+				s.Text(), "name", c.Name)
+		}
+		// Drain the pipe if the line was too long.
+		io.Copy(io.Discard, r)
+	}()
+
+	slog.Info("Starting companion", "name", c.Name, "cmd", cmd)
+	err = cmd.Run()
+	w.Close()
+	<-done
+	if err != nil {
+		return fmt.Errorf("%s: %w", c.Command, err)
+	}
+	return nil
+}
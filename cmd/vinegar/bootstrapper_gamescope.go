@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"codeberg.org/puregotk/puregotk/v4/gdk"
+	"github.com/vinegarhq/vinegar/internal/config"
+	"github.com/vinegarhq/vinegar/internal/gutil"
+)
+
+// gamescope returns the gamescope wrapper to run Studio with, and the
+// inner resolution Studio is rendered at within gamescope.
+func (b *bootstrapper) gamescope() (config.Wrapper, string) {
+	g := &b.cfg.Studio.Gamescope
+	var args []string
+
+	w, h, refresh := monitorMode()
+	if g.Output != "" {
+		w, h, _ = config.ParseResolution(g.Output)
+	}
+	if g.Refresh > 0 {
+		refresh = g.Refresh
+	}
+
+	// Studio is rendered at the configured inner resolution, otherwise
+	// at the virtual desktop's resolution, and otherwise at the output
+	// resolution. A virtual desktop is resized to the inner resolution
+	// by command, so that it is never scaled by gamescope within it.
+	inner := fmt.Sprintf("%dx%d", w, h)
+	if _, _, err := config.ParseResolution(g.Inner); err == nil {
+		inner = g.Inner
+	} else if _, _, err := config.ParseResolution(b.cfg.Studio.Desktop); err == nil {
+		inner = b.cfg.Studio.Desktop
+	}
+	iw, ih, _ := config.ParseResolution(inner)
+
+	if w > 0 && h > 0 {
+		args = append(args, "-W", strconv.Itoa(w), "-H", strconv.Itoa(h))
+	}
+	if iw > 0 && ih > 0 {
+		args = append(args, "-w", strconv.Itoa(iw), "-h", strconv.Itoa(ih))
+	}
+	if refresh > 0 {
+		args = append(args, "-r", strconv.Itoa(refresh))
+	}
+	if g.Upscaler != "" {
+		args = append(args, "-F", g.Upscaler)
+	}
+	if g.HDR {
+		args = append(args, "--hdr-enabled")
+	}
+	if g.ExposeWayland {
+		args = append(args, "--expose-wayland")
+	}
+
+	return config.Wrapper{
+		Command: "gamescope",
+		Args:    append(args, "--"),
+		Enabled: true,
+	}, inner
+}
+
+// monitorMode returns the resolution and refresh rate of the first
+// monitor, or zero if unknown. It must not be called on the main thread.
+func monitorMode() (w, h, refresh int) {
+	done := make(chan struct{})
+	gutil.IdleAdd(func() {
+		defer close(done)
+		display := gdk.DisplayGetDefault()
+		if display == nil {
+			return
+		}
+		monitors := display.GetMonitors()
+		if monitors.GetNItems() == 0 {
+			return
+		}
+
+		m := gdk.MonitorNewFromInternalPtr(monitors.GetItem(0))
+		defer m.Unref()
+		var geom gdk.Rectangle
+		m.GetGeometry(&geom)
+		scale := m.GetScale()
+		w = int(float64(geom.Width) * scale)
+		h = int(float64(geom.Height) * scale)
+		refresh = int(m.GetRefreshRate()+500) / 1000 // millihertz
+	})
+	<-done
+	return
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"codeberg.org/puregotk/puregotk/v4/glib"
+	"github.com/vinegarhq/vinegar/internal/gutil"
+	"github.com/vinegarhq/vinegar/internal/netutil"
+
+	. "github.com/pojntfx/go-gettext/pkg/i18n"
+)
+
+// progressReporter is a netutil.Reporter that shows the progress of its
+// transfers in the bootstrapper's progress bar, alongside their transfer
+// rate and estimated time remaining in the information label.
+type progressReporter struct {
+	netutil.Progress
+
+	b      *bootstrapper
+	mu     sync.Mutex
+	active int
+	id     uint32
+}
+
+func (b *bootstrapper) reporter() *progressReporter {
+	return &progressReporter{b: b}
+}
+
+// Start implements netutil.Reporter.
+func (r *progressReporter) Start(total int64) {
+	r.Progress.Start(total)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.active++
+	if r.active > 1 {
+		return
+	}
+
+	var tcb glib.SourceFunc = func(uintptr) bool {
+		r.update()
+		return true
+	}
+	r.id = glib.TimeoutAdd(128, &tcb, 0)
+}
+
+// Done implements netutil.Reporter.
+func (r *progressReporter) Done() {
+	r.finish()
+}
+
+// Error implements netutil.Reporter.
+func (r *progressReporter) Error(error) {
+	r.finish()
+}
+
+func (r *progressReporter) finish() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.active--
+	if r.active > 0 {
+		return
+	}
+
+	glib.SourceRemove(r.id)
+	gutil.IdleAdd(func() {
+		r.b.info.SetLabel("")
+		if r.b.bin != nil {
+			r.b.info.SetLabel(r.b.bin.Channel)
+		}
+	})
+}
+
+func (r *progressReporter) update() {
+	r.b.pbar.SetFraction(r.Fraction())
+	if r.Total() <= 0 {
+		return
+	}
+	r.b.info.SetLabel(fmt.Sprintf(L("%s of %s (%s/s, %s left)"),
+		glib.FormatSize(uint64(r.Current())),
+		glib.FormatSize(uint64(r.Total())),
+		glib.FormatSize(uint64(r.Rate())),
+		r.ETA().Round(time.Second),
+	))
+}
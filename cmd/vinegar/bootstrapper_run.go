@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"os/exec"
 	"os/signal"
 	"path/filepath"
 	"strings"
@@ -12,11 +13,16 @@ import (
 	"codeberg.org/puregotk/puregotk/v4/glib"
 	. "github.com/pojntfx/go-gettext/pkg/i18n"
 	"github.com/sewnie/wine"
+	"github.com/vinegarhq/vinegar/internal/config"
 	"github.com/vinegarhq/vinegar/internal/gutil"
+	"github.com/vinegarhq/vinegar/internal/sysinfo"
 )
 
+// Name of the Studio executable within a deployment's directory.
+const studioExecutable = "RobloxStudioBeta.exe"
+
 func (b *bootstrapper) commandPath() string {
-	return filepath.Join(b.dir, "RobloxStudioBeta.exe")
+	return filepath.Join(b.dir, studioExecutable)
 }
 
 func (b *bootstrapper) command(args ...string) (*wine.Cmd, error) {
@@ -26,28 +32,87 @@ func (b *bootstrapper) command(args ...string) (*wine.Cmd, error) {
 	}
 
 	// This is an authentication call, which is ran to the main Studio instance,
-	// no point to run this with the launcher or seperate desktop.
+	// no point to run this with the launcher, gamescope or seperate desktop.
 	if len(args) > 0 && strings.HasPrefix(args[0], "roblox-studio-auth:") {
 		return cmd, nil
 	}
 
+	// The command is ran as: gamescope, the launcher and enabled wrappers,
+	// the sandbox, then Wine. Gamescope runs first so that wrappers such
+	// as mangohud apply to Studio within it, rather than to gamescope.
+	chain := b.cfg.Studio.Chain()
+	desktop := b.cfg.Studio.Desktop
+	if b.cfg.Studio.Gamescope.Enabled {
+		scope, inner := b.gamescope()
+		chain = append([]config.Wrapper{scope}, chain...)
+		// The virtual desktop must fill gamescope's inner resolution.
+		if desktop != "" {
+			desktop = inner
+		}
+	}
+	if b.cfg.Studio.Sandbox.Enabled {
+		sandbox, err := b.sandbox()
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, sandbox)
+	}
+
 	// I was called a "noob" for my implementation by someone who creates
 	// an entirely new Wineprefix for this feature.
-	if d := string(b.cfg.Studio.Desktop); d != "" {
+	if d := desktop; d != "" {
 		cmd.Args = append([]string{
 			cmd.Args[0],
 			"explorer", "/desktop=" + glib.UuidStringRandom() + "," + d,
 		}, cmd.Args[1:]...)
 	}
 
-	launcher := strings.Fields(b.cfg.Studio.Launcher)
-	if len(launcher) >= 1 {
-		cmd.Args = append(launcher, cmd.Args...)
-		p, err := b.cfg.Studio.LauncherPath()
+	// umu-run takes the place of Wine, running the command with its
+	// own Proton runtime, or the one set in PROTONPATH.
+	if b.cfg.Studio.UMU {
+		umu, err := exec.LookPath("umu-run")
+		if err != nil {
+			return nil, fmt.Errorf("umu: %w", err)
+		}
+		cmd.Args = append([]string{umu}, cmd.Args[1:]...)
+		cmd.Path = umu
+	}
+
+	for _, w := range chain {
+		for k, v := range w.Env {
+			cmd.Env = append(cmd.Environ(), k+"="+v)
+		}
+	}
+
+	// Each wrapper runs the command of the next, ending with Wine.
+	var wrappers []string
+	host := false
+	for _, w := range chain {
+		p, onHost, err := w.Path()
 		if err != nil {
-			return nil, fmt.Errorf("bad launcher: %w", err)
+			return nil, fmt.Errorf("%s: %w", w.Command, err)
+		}
+		// A wrapper only present on the Flatpak host runs the rest of
+		// the command on the host, which lacks the command environment,
+		// so every wrapper following it must be found on the host too.
+		if host && !onHost {
+			p, err = sysinfo.HostLookPath(w.Command)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", w.Command, err)
+			}
 		}
-		cmd.Path = p
+		if onHost && !host {
+			host = true
+			wrappers = append(wrappers, sysinfo.HostCommand(sysinfo.HostEnv(cmd.Environ()))...)
+		}
+		wrappers = append(append(wrappers, p), w.Args...)
+	}
+	if host && !sysinfo.HostVisible(cmd.Path) {
+		return nil, fmt.Errorf("%s is not accessible to wrappers on the host", cmd.Path)
+	}
+	if len(wrappers) > 0 {
+		cmd.Args = append(wrappers, cmd.Args...)
+		cmd.Path = wrappers[0]
 	}
 
 	return cmd, nil
@@ -82,8 +147,16 @@ func (b *bootstrapper) execute(args ...string) error {
 	}
 
 	b.count++
+	if b.count == 1 {
+		gutil.IdleAdd(b.startUpdates)
+		b.startCompanions()
+	}
 	defer func() {
 		b.count--
+		if b.count == 0 {
+			gutil.IdleAdd(b.stopUpdates)
+			b.stopCompanions()
+		}
 	}()
 
 	gutil.IdleAdd(func() {
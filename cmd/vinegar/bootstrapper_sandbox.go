@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/adrg/xdg"
+	"github.com/vinegarhq/vinegar/internal/config"
+	"github.com/vinegarhq/vinegar/internal/dirs"
+	"github.com/vinegarhq/vinegar/internal/sysinfo"
+)
+
+var (
+	errSandboxFlatpak = errors.New("sandbox is unavailable in Flatpak, which is already sandboxed")
+	errSandboxUMU     = errors.New("sandbox is unavailable with umu-run, whose runtime is not permitted within it")
+)
+
+// sandbox returns the bubblewrap wrapper to run Wine with, which only
+// permits access to the system, the Wineprefix, Studio, the configured
+// folders, and the devices and sockets required for Studio to function.
+//
+// Only Studio is sandboxed: the wineserver and the Wineprefix's services,
+// started beforehand by prepareWine, run outside of the sandbox and
+// retain access to the entire filesystem, including through the Z: drive.
+func (b *bootstrapper) sandbox() (config.Wrapper, error) {
+	if sysinfo.Flatpak {
+		return config.Wrapper{}, errSandboxFlatpak
+	}
+	if b.cfg.Studio.UMU {
+		return config.Wrapper{}, errSandboxUMU
+	}
+
+	args := []string{
+		"--die-with-parent",
+		"--new-session",
+		"--unshare-uts",
+		"--unshare-cgroup-try",
+		"--proc", "/proc",
+		"--dev", "/dev",
+		"--ro-bind", "/sys", "/sys",
+		"--tmpfs", "/tmp",
+	}
+	bind := func(opt string, paths ...string) {
+		for _, p := range paths {
+			if p != "" {
+				args = append(args, opt, p, p)
+			}
+		}
+	}
+
+	bind("--ro-bind-try",
+		"/usr", "/bin", "/sbin", "/lib", "/lib32", "/lib64", "/etc", "/opt",
+		"/nix", "/run/opengl-driver", "/run/systemd/resolve")
+
+	// GPU device nodes, and shared memory used by Wine synchronization
+	// primitives with the wineserver outside of the sandbox.
+	bind("--dev-bind-try", "/dev/dri", "/dev/shm")
+	nvidia, _ := filepath.Glob("/dev/nvidia*")
+	bind("--dev-bind-try", nvidia...)
+
+	// Wine will not function without the wineserver's socket.
+	bind("--bind-try", fmt.Sprintf("/tmp/.wine-%d", os.Getuid()))
+
+	bind("--ro-bind-try", "/tmp/.X11-unix", os.Getenv("XAUTHORITY"))
+	if wl := os.Getenv("WAYLAND_DISPLAY"); wl != "" {
+		if !filepath.IsAbs(wl) {
+			wl = filepath.Join(xdg.RuntimeDir, wl)
+		}
+		bind("--ro-bind-try", wl)
+	}
+	bind("--ro-bind-try",
+		filepath.Join(xdg.RuntimeDir, "pulse"),
+		filepath.Join(xdg.RuntimeDir, "pipewire-0"),
+		sessionBus())
+
+	// The Kombucha wine root is a link to the build in use.
+	if root := b.cfg.Studio.WineRoot; root != "" {
+		src, err := filepath.EvalSymlinks(root)
+		if err != nil {
+			return config.Wrapper{}, fmt.Errorf("sandbox: %w", err)
+		}
+		args = append(args, "--ro-bind", src, root)
+	}
+
+	// The Wineprefix's Local AppData is redirected to AppDataPath, where
+	// Studio keeps its settings and plugins, and its Documents and Pictures
+	// to the user's, where Studio saves places and screenshots. The cache
+	// is not permitted, which only leaves DXVK without its state cache.
+	bind("--bind",
+		b.pfx.Dir(),
+		dirs.Versions)
+	bind("--bind-try",
+		dirs.AppDataPath,
+		xdg.UserDirs.Documents,
+		xdg.UserDirs.Pictures)
+	bind("--bind-try", b.cfg.Studio.Sandbox.Paths...)
+	for _, dir := range b.cfg.Studio.Drives {
+		bind("--bind-try", dir)
+	}
+
+	return config.Wrapper{
+		Command: "bwrap",
+		Args:    append(args, "--"),
+		Enabled: true,
+	}, nil
+}
+
+// sessionBus returns the path to the socket of the session bus.
+func sessionBus() string {
+	addr := os.Getenv("DBUS_SESSION_BUS_ADDRESS")
+	for opt := range strings.SplitSeq(strings.TrimPrefix(addr, "unix:"), ",") {
+		if p, ok := strings.CutPrefix(opt, "path="); ok {
+			return p
+		}
+	}
+	return filepath.Join(xdg.RuntimeDir, "bus")
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -23,7 +24,7 @@ import (
 //   - Install Roblox
 //   - Install DXVK
 //   - Install WebView
-func (b *bootstrapper) setupExecute() error {
+func (b *bootstrapper) setupExecute(ctx context.Context) error {
 	if b.count > 0 {
 		slog.Info("Skipping setup!", "ver", b.bin.GUID)
 		return nil
@@ -43,7 +44,7 @@ func (b *bootstrapper) setupExecute() error {
 		slog.Warn("Retrieving authenticated user failed", "err", err)
 	}
 
-	if err := b.updateDeployment(); err != nil {
+	if err := b.updateDeployment(ctx); err != nil {
 		return err
 	}
 
@@ -57,13 +58,23 @@ func (b *bootstrapper) setupExecute() error {
 		return fmt.Errorf("fflags: %w", err)
 	}
 
+	if err := b.syncPluginsFolder(); err != nil {
+		slog.Warn("Syncing plugins folder failed", "err", err)
+	}
+
 	// Does nothing if WebView is disabled, preferred to download
 	// a large installer before Wineprefix initialization.
 	// before Wineprefix initialization.
-	if err := b.downloadWebView(webview); err != nil {
+	if err := b.downloadWebView(ctx, webview); err != nil {
 		return fmt.Errorf("download webview: %w", err)
 	}
 
+	// Snapshots can only be taken while the Wineprefix is not
+	// running, which prepareWine will start.
+	if webview != "" && webview != b.cfg.Studio.WebView {
+		b.autoSnapshot("webview")
+	}
+
 	stop := b.performing()
 	defer stop()
 
@@ -71,13 +82,17 @@ func (b *bootstrapper) setupExecute() error {
 	// setting up Vinegar's registry values as necessary, along
 	// with restoring settings. After the wineserver is ran,
 	// it is safe to install DXVK, WebView, and other modifications.
-	if _, err := b.app.prepareWine(); err != nil {
+	if _, err := b.app.prepareWine(ctx); err != nil {
 		return err
 	}
 
+	if err := b.mapDrives(); err != nil {
+		return fmt.Errorf("drives: %w", err)
+	}
+
 	stop()
 
-	if err := b.installWebView(webview); err != nil {
+	if err := b.installWebView(ctx, webview); err != nil {
 		return fmt.Errorf("install webview: %w", err)
 	}
 
@@ -85,7 +100,7 @@ func (b *bootstrapper) setupExecute() error {
 	// giving the wineserver the persistent timeout until another program
 	// is executed. Attempt to reduce chances of being killed by installing
 	// DXVK only before running Studio, which leaves the server open.
-	if err := b.setupDXVK(); err != nil {
+	if err := b.setupDXVK(ctx); err != nil {
 		return fmt.Errorf("dxvk: %w", err)
 	}
 
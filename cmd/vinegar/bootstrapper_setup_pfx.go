@@ -3,6 +3,7 @@ package main
 import (
 	"archive/tar"
 	"compress/gzip"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -15,13 +16,14 @@ import (
 	"github.com/sewnie/wine/dxvk"
 	"github.com/sewnie/wine/peutil"
 	"github.com/sewnie/wine/webview2"
+	"github.com/vinegarhq/vinegar/internal/config"
 	"github.com/vinegarhq/vinegar/internal/dirs"
 	"github.com/vinegarhq/vinegar/internal/netutil"
 
 	. "github.com/pojntfx/go-gettext/pkg/i18n"
 )
 
-func (b *bootstrapper) setupDXVK() error {
+func (b *bootstrapper) setupDXVK(ctx context.Context) error {
 	version := b.cfg.Studio.DXVKVersion()
 	if version == "" {
 		return nil
@@ -51,20 +53,10 @@ func (b *bootstrapper) setupDXVK() error {
 	b.message(L("Downloading DXVK"), "current", installed, "new", version)
 
 	name := filepath.Join(dirs.Cache, "dxvk-"+version+".tar.gz")
-	if _, err := os.Stat(name); err == nil {
-		goto install
-	}
-
-	if err := os.MkdirAll(dirs.Cache, 0o755); err != nil {
-		return fmt.Errorf("prepare cache: %w", err)
-	}
-
-	if err := netutil.DownloadProgress(
-		dxvk.URL(version), name, &b.pbar); err != nil {
-		return fmt.Errorf("download: %w", err)
+	if err := b.downloadDXVK(ctx, version, name); err != nil {
+		return err
 	}
 
-install:
 	defer b.performing()()
 
 	if native != "" {
@@ -83,14 +75,45 @@ install:
 		_ = dxvk.Restore(b.pfx)
 	}
 
+	b.message(L("Extracting DXVK"), "version", version)
+	return extractDXVK(ctx, name, b.dir)
+}
+
+// downloadDXVK ensures the named DXVK tarball in the cache is intact,
+// downloading it again if it is missing, corrupt or truncated. The
+// tarball must match the checksum pinned for its version.
+func (b *bootstrapper) downloadDXVK(ctx context.Context, version, name string) error {
+	sum := config.DXVKChecksum(version)
+	if sum == "" {
+		return fmt.Errorf("no checksum known for DXVK %s", version)
+	}
+
+	err := netutil.VerifyFile(name, sum)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		slog.Warn("Cached DXVK is invalid, downloading again", "err", err)
+	}
+
+	if err := os.MkdirAll(dirs.Cache, 0o755); err != nil {
+		return fmt.Errorf("prepare cache: %w", err)
+	}
+
+	return b.downloadCached(ctx, dxvk.URL(version), name, sum)
+}
+
+// extractDXVK installs the 64-bit DLLs of the named DXVK tarball
+// into dir. Partially installed DLLs are removed on failure or
+// cancellation, leaving the deployment to use WineD3D until the
+// next setup.
+func extractDXVK(ctx context.Context, name, dir string) (err error) {
 	f, err := os.Open(name)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
 
-	b.message(L("Extracting DXVK"), "version", version)
-
 	zr, err := gzip.NewReader(f)
 	if err != nil {
 		return err
@@ -99,7 +122,21 @@ install:
 
 	tr := tar.NewReader(zr)
 
+	var installed []string
+	defer func() {
+		if err == nil {
+			return
+		}
+		for _, name := range installed {
+			_ = os.Remove(name)
+		}
+	}()
+
 	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		hdr, err := tr.Next()
 		if err != nil {
 			if err == io.EOF {
@@ -121,13 +158,14 @@ install:
 			continue
 		}
 
-		name := filepath.Join(b.dir, filepath.Base(hdr.Name))
+		name := filepath.Join(dir, filepath.Base(hdr.Name))
 		slog.Debug("Installing DXVK DLL locally", "dest", name)
 
 		f, err := os.Create(name)
 		if err != nil {
 			return err
 		}
+		installed = append(installed, name)
 
 		if _, err = io.Copy(f, tr); err != nil {
 			f.Close()
@@ -157,7 +195,14 @@ func (b *bootstrapper) webViewVersion(offline *wine.Registry) string {
 	}
 
 	if v := k.GetValue("DisplayVersion"); v != nil {
-		return v.Data.(string)
+		version := v.Data.(string)
+		// Snapshots of the Wineprefix exclude the WebView files.
+		app := filepath.Join(b.pfx.Dir(), filepath.FromSlash(webViewDir), "Application", version)
+		if _, err := os.Stat(app); err != nil {
+			slog.Warn("WebView2 installation missing, assuming uninstalled", "version", version)
+			return ""
+		}
+		return version
 	}
 
 	slog.Warn("WebView2 installed status missing, assuming uninstalled")
@@ -167,18 +212,21 @@ func (b *bootstrapper) webViewVersion(offline *wine.Registry) string {
 // downloadWebView, when WebView is enabled in the Studio configuration,
 // will prepare the WebView installer, prior to initializing the wineprefix
 // and running the installer.
-func (b *bootstrapper) downloadWebView(installed string) error {
-	defer b.performing()()
+func (b *bootstrapper) downloadWebView(ctx context.Context, installed string) error {
+	stop := b.performing()
+	defer stop()
 	inst := b.webViewInstaller()
 	if installed == b.cfg.Studio.WebView || b.cfg.Studio.WebView == "" {
 		return nil
 	}
 
-	// Ensures the installer is a valid executable
-	f, err := peutil.Open(inst)
-	if err == nil {
-		f.Close()
-		return nil
+	// Ensures the installer is a valid executable, as downloaded
+	if err := verifyCached(inst); err == nil {
+		f, err := peutil.Open(inst)
+		if err == nil {
+			f.Close()
+			return nil
+		}
 	}
 
 	b.message(L("Fetching WebView"), "upload", b.cfg.Studio.WebView)
@@ -190,14 +238,20 @@ func (b *bootstrapper) downloadWebView(installed string) error {
 		return fmt.Errorf("fetch: %w", err)
 	}
 
+	sum, err := netutil.DecodeSum(d.Delivery.Hashes.Sha256)
+	if err != nil {
+		return err
+	}
+
+	stop()
 	b.message(L("Downloading WebView"), "catalog", d.Delivery.CatalogID)
-	return netutil.DownloadProgress(d.URL, inst, &b.pbar)
+	return b.downloadCached(ctx, d.URL, inst, sum)
 }
 
 // installWebView checks the Studio WebView version and installs WebView
 // if the version is out of date or requires installation. The previous
 // version of WebView will be uninstalled before any installation.
-func (b *bootstrapper) installWebView(installed string) error {
+func (b *bootstrapper) installWebView(ctx context.Context, installed string) error {
 	version := b.cfg.Studio.WebView
 
 	b.message(L("Checking WebView"), "against", version)
@@ -212,6 +266,11 @@ func (b *bootstrapper) installWebView(installed string) error {
 		return nil
 	}
 
+	// The installer cannot be interrupted once ran.
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	inst := b.webViewInstaller()
 	b.message(L("Installing WebView"), "version", version, "path", inst)
 	defer b.performing()()
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -8,7 +9,7 @@ import (
 	"path/filepath"
 	"slices"
 	"sort"
-	"sync/atomic"
+	"strings"
 
 	"github.com/sewnie/rbxbin"
 	"github.com/sewnie/rbxweb"
@@ -16,6 +17,7 @@ import (
 	"github.com/vinegarhq/vinegar/internal/dirs"
 	"github.com/vinegarhq/vinegar/internal/gutil"
 	"github.com/vinegarhq/vinegar/internal/netutil"
+	"github.com/vinegarhq/vinegar/internal/state"
 	"golang.org/x/sync/errgroup"
 
 	. "github.com/pojntfx/go-gettext/pkg/i18n"
@@ -24,26 +26,80 @@ import (
 var (
 	studio     = rbxweb.BinaryTypeWindowsStudio64
 	channelKey = `HKCU\Software\ROBLOX Corporation\Environments\RobloxStudio\Channel`
+
+	// Name of the file in a mirror's channel directory that holds
+	// the GUID of the latest Studio deployment.
+	versionFile = "versionQTStudio"
 )
 
-func (b *bootstrapper) updateDeployment() error {
-	stop := b.performing()
+// deployment returns the Studio deployment to use, which is either the
+// forced version, the version advertised by the configured mirror, or
+// the latest version from Roblox.
+func (a *app) deployment(ctx context.Context) (*rbxbin.Deployment, error) {
+	d := &rbxbin.Deployment{
+		Type:    studio,
+		Channel: a.cfg.Studio.Channel,
+		GUID:    a.cfg.Studio.ForcedVersion,
+	}
+	if d.GUID != "" {
+		return d, nil
+	}
 
-	b.message(L("Checking for updates"))
-	if b.cfg.Studio.ForcedVersion == "" {
-		d, err := rbxbin.GetDeployment(b.rbx, studio, b.cfg.Studio.Channel)
+	// A configured mirror is likely to be used on machines without
+	// internet access, where the Roblox client settings API is
+	// unreachable.
+	u, err := a.mirrorURL()
+	if err != nil {
+		return nil, err
+	}
+	if u != "" {
+		url := mirrorChannelURL(rbxbin.Mirror(u), d) + "/" + versionFile
+		guid, err := netutil.Body(ctx, url)
 		if err != nil {
-			stop()
-			return fmt.Errorf("fetch: %w", err)
-		}
-		b.bin = d
-	} else {
-		b.bin = &rbxbin.Deployment{
-			Type:    studio,
-			Channel: b.cfg.Studio.Channel,
-			GUID:    b.cfg.Studio.ForcedVersion,
+			return nil, fmt.Errorf("mirror version: %w", err)
 		}
+		d.GUID = strings.TrimSpace(guid)
+		return d, nil
+	}
+
+	return rbxbin.GetDeployment(a.rbx, studio, a.cfg.Studio.Channel)
+}
+
+// mirror returns the configured deployment mirror, or the
+// fastest available Roblox mirror if none is set.
+func (a *app) mirror() (rbxbin.Mirror, error) {
+	u, err := a.mirrorURL()
+	if err != nil {
+		return "", err
+	}
+	if u != "" {
+		slog.Info("Using configured mirror", "url", u)
+		return rbxbin.Mirror(u), nil
 	}
+	return rbxbin.GetMirror()
+}
+
+// mirrorChannelURL returns the directory of the given mirror that
+// contains the files of the deployment's channel.
+func mirrorChannelURL(m rbxbin.Mirror, d *rbxbin.Deployment) string {
+	url := m.PackageURL(&rbxbin.Deployment{
+		Type:    d.Type,
+		Channel: d.Channel,
+		GUID:    "version",
+	}, versionFile)
+	return url[:strings.LastIndex(url, "/")]
+}
+
+func (b *bootstrapper) updateDeployment(ctx context.Context) error {
+	stop := b.performing()
+
+	b.message(L("Checking for updates"))
+	d, err := b.deployment(ctx)
+	if err != nil {
+		stop()
+		return fmt.Errorf("fetch: %w", err)
+	}
+	b.bin = d
 
 	gutil.IdleAdd(func() {
 		b.info.SetLabel(b.bin.Channel)
@@ -64,13 +120,12 @@ func (b *bootstrapper) updateDeployment() error {
 	if err == nil {
 		f.Close()
 		b.message(L("Up to date"), "guid", b.bin.GUID)
+		b.recordDeployment()
 		return nil
 	}
 
 	b.message(L("Installing Studio"),
 		"new", b.bin.GUID, "reason", errors.Unwrap(err))
-	// Remove all other deployments
-	removeUniqueFiles(dirs.Versions, []string{b.bin.GUID})
 
 	if err := os.MkdirAll(dirs.Downloads, 0o755); err != nil {
 		return err
@@ -78,10 +133,14 @@ func (b *bootstrapper) updateDeployment() error {
 
 	stop()
 
-	if err := b.installDeployment(); err != nil {
+	if err := b.installDeployment(ctx); err != nil {
 		return err
 	}
 
+	// Remove all other deployments only once the new deployment is
+	// installed, as the installation may be cancelled or fail.
+	removeUniqueFiles(dirs.Versions, []string{b.bin.GUID})
+
 	defer b.performing()()
 
 	b.message(L("Writing AppSettings"))
@@ -100,16 +159,41 @@ func (b *bootstrapper) updateDeployment() error {
 		}
 	}
 
+	b.recordDeployment()
 	slog.Info("Successfully installed!", "guid", b.bin.GUID)
 	return nil
 }
 
-func (b *bootstrapper) installDeployment() error {
+// recordDeployment records the deployment in use as the
+// installed deployment, for it to be exported as a mirror.
+func (b *bootstrapper) recordDeployment() {
+	if err := state.Update(func(s *state.State) {
+		s.Studio = state.Studio{GUID: b.bin.GUID, Channel: b.bin.Channel}
+	}); err != nil {
+		slog.Error("Failed to record deployment", "err", err)
+	}
+}
+
+func (b *bootstrapper) installDeployment(ctx context.Context) error {
 	stop := b.performing()
 	defer stop()
 
+	// A prefetch may be downloading the same packages, which
+	// are then reused once it has finished.
+	select {
+	case b.downloads <- struct{}{}:
+	default:
+		b.message(L("Waiting for Downloads"))
+		select {
+		case b.downloads <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	defer func() { <-b.downloads }()
+
 	b.message(L("Finding Mirror"))
-	m, err := rbxbin.GetMirror()
+	m, err := b.mirror()
 	if err != nil {
 		return fmt.Errorf("fetch mirror: %w", err)
 	}
@@ -136,81 +220,137 @@ func (b *bootstrapper) installDeployment() error {
 	})
 
 	b.message(L("Fetching Installation Directives"))
-	pd, err := m.BinaryDirectories(b.bin)
+	pd, err := b.packageDirectories(ctx, m)
 	if err != nil {
 		return fmt.Errorf("fetch package dirs: %w", err)
 	}
 
 	stop()
 
-	return b.installPackages(&m, pkgs, pd)
+	if err := b.installPackages(ctx, &m, pkgs, pd); err != nil {
+		return err
+	}
+
+	// Exporting a mirror is optional, and must not fail the installation.
+	if err := b.saveMirrorFiles(ctx, m, pd); err != nil {
+		slog.Warn("Failed to save deployment manifests", "err", err)
+	}
+	return nil
 }
 
 func (b *bootstrapper) installPackages(
+	ctx context.Context,
 	mirror *rbxbin.Mirror,
 	pkgs []rbxbin.Package,
 	pdirs rbxbin.PackageDirectories,
 ) error {
-	total := len(pkgs)
-	finished := int64(0)
-	group := new(errgroup.Group)
+	group, ctx := b.downloadGroup(ctx)
+
+	// Each package is weighted by its size twice, once for its
+	// download, and another for its extraction.
+	var total int64
+	for _, pkg := range pkgs {
+		total += 2 * pkg.ZipSize
+	}
+	r := b.reporter()
+	r.Start(total)
+	p := netutil.Nested(r)
 
 	b.message(L("Installing Packages"), "count", len(pkgs), "dir", b.dir)
 	for _, pkg := range pkgs {
 		group.Go(func() error {
-			if err := b.installPackage(mirror, pdirs, &pkg); err != nil {
-				return err
-			}
-
-			atomic.AddInt64(&finished, 1)
-			gutil.IdleAdd(func() {
-				b.pbar.SetFraction(float64(finished) / float64(total))
-			})
-
-			return nil
+			return b.installPackage(ctx, mirror, pdirs, &pkg, p)
 		})
 	}
 
 	if err := group.Wait(); err != nil {
+		r.Error(err)
 		os.RemoveAll(b.dir)
 		return err
 	}
 
+	r.Done()
 	return nil
 }
 
 func (b *bootstrapper) installPackage(
+	ctx context.Context,
 	mirror *rbxbin.Mirror,
 	pdirs rbxbin.PackageDirectories,
 	pkg *rbxbin.Package,
+	p netutil.Reporter,
 ) error {
 	slog := slog.With("name", pkg.Name)
 
 	switch pkg.Name {
 	case "RobloxStudioInstaller.exe":
 		slog.Warn("Skipping package!")
+		p.Skip(2 * pkg.ZipSize)
 		return nil
 	}
 
-	src := filepath.Join(dirs.Downloads, pkg.Checksum)
 	dst, ok := pdirs[pkg.Name]
 	if !ok {
 		return fmt.Errorf("unhandled: %s", pkg.Name)
 	}
 
-	if err := pkg.Verify(src); err != nil {
-		url := mirror.PackageURL(b.bin, pkg.Name)
-		slog.Info("Downloading package", "sum", pkg.Checksum)
-		if err := netutil.Download(url, src); err != nil {
-			return err
-		}
-		if err := pkg.Verify(src); err != nil {
-			return err
-		}
+	src, err := downloadPackage(ctx, mirror, b.bin, pkg, p)
+	if err != nil {
+		return err
+	}
+
+	// Extraction cannot be interrupted, stop before it instead.
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
 	slog.Info("Extracting package", "dest", dst)
-	return pkg.Extract(src, filepath.Join(b.dir, dst))
+	if err := pkg.Extract(src, filepath.Join(b.dir, dst)); err != nil {
+		return err
+	}
+	p.Bytes(pkg.ZipSize)
+
+	return nil
+}
+
+// downloadPackage ensures the given package of the deployment is present
+// and valid in the downloads directory, and returns its path. The
+// package's download is reported to p, if non-nil.
+func downloadPackage(
+	ctx context.Context,
+	mirror *rbxbin.Mirror,
+	d *rbxbin.Deployment,
+	pkg *rbxbin.Package,
+	p netutil.Reporter,
+) (string, error) {
+	src := filepath.Join(dirs.Downloads, pkg.Checksum)
+	if err := pkg.Verify(src); err == nil {
+		if p != nil {
+			p.Skip(pkg.ZipSize)
+		}
+		return src, nil
+	}
+
+	url := mirror.PackageURL(d, pkg.Name)
+	slog.Info("Downloading package", "name", pkg.Name, "sum", pkg.Checksum)
+	if err := netutil.Download(ctx, url, src, p); err != nil {
+		return "", err
+	}
+	if err := pkg.Verify(src); err != nil {
+		return "", err
+	}
+
+	return src, nil
+}
+
+// downloadGroup returns a new group derived from ctx, limited to
+// the configured download concurrency.
+func (a *app) downloadGroup(ctx context.Context) (*errgroup.Group, context.Context) {
+	group, ctx := errgroup.WithContext(ctx)
+	if n := a.cfg.Studio.DownloadConcurrency; n > 0 {
+		group.SetLimit(n)
+	}
+	return group, ctx
 }
 
 func removeUniqueFiles(dir string, included []string) {
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"codeberg.org/puregotk/puregotk/v4/gio"
+	"codeberg.org/puregotk/puregotk/v4/glib"
+	"github.com/vinegarhq/vinegar/internal/gutil"
+
+	. "github.com/pojntfx/go-gettext/pkg/i18n"
+)
+
+// Interval in seconds between update checks while Studio is running.
+const updateCheckInterval = 30 * 60
+
+// connectUpdate registers the application action used by the
+// update notification to accept the update.
+func (b *bootstrapper) connectUpdate() {
+	action := gio.NewSimpleAction("studio-update", nil)
+	activate := func(_ gio.SimpleAction, _ uintptr) {
+		slog.Info("Studio update accepted, relaunching once all instances exit")
+		b.updateMu.Lock()
+		b.pending = true
+		b.updateMu.Unlock()
+		go func() {
+			if err := b.prefetch(context.Background(), nil); err != nil {
+				slog.Error("Failed to prefetch deployment", "err", err)
+			}
+		}()
+	}
+	action.ConnectActivate(&activate)
+	b.app.AddAction(action)
+	action.Unref()
+}
+
+// startUpdates periodically checks for a new deployment while Studio
+// is running, as the setup is skipped for any further instances.
+// It must be called from the main thread, as with stopUpdates.
+func (b *bootstrapper) startUpdates() {
+	if b.updates != 0 {
+		return
+	}
+	var cb glib.SourceFunc = func(uintptr) bool {
+		go b.checkUpdate()
+		return true
+	}
+	b.updates = glib.TimeoutAddSeconds(updateCheckInterval, &cb, 0)
+}
+
+// stopUpdates stops the periodic update check, if it is running.
+func (b *bootstrapper) stopUpdates() {
+	if b.updates == 0 {
+		return
+	}
+	glib.SourceRemove(b.updates)
+	b.updates = 0
+}
+
+// checkUpdate fetches the latest deployment, and notifies the user from
+// the main thread if it differs from the running or announced deployment.
+func (b *bootstrapper) checkUpdate() {
+	d, err := b.deployment(context.Background())
+	if err != nil {
+		slog.Error("Failed to check for updates", "err", err)
+		return
+	}
+
+	gutil.IdleAdd(func() {
+		b.updateMu.Lock()
+		defer b.updateMu.Unlock()
+		if d.GUID == b.bin.GUID || d.GUID == b.latest {
+			return
+		}
+		b.latest = d.GUID
+		slog.Info("New deployment available", "current", b.bin.GUID, "new", d.GUID)
+
+		n := gio.NewNotification(L("Studio Update Available"))
+		n.SetBody(L("A new version of Studio was released. It can be installed in the background, and Studio relaunched once all of its windows are closed."))
+		n.AddButton(L("Update and Relaunch"), "app.studio-update")
+		b.app.SendNotification("studio-update", n)
+	})
+}
+
+// relaunch reports whether an accepted update should be installed
+// and Studio relaunched, as no instances remain.
+func (b *bootstrapper) relaunch() bool {
+	b.updateMu.Lock()
+	defer b.updateMu.Unlock()
+	if b.count > 0 || !b.pending {
+		return false
+	}
+	b.pending = false
+	b.app.WithdrawNotification("studio-update")
+	return true
+}
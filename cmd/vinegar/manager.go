@@ -2,18 +2,18 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"path/filepath"
-	"strings"
 
 	"codeberg.org/puregotk/puregotk/v4/adw"
 	"codeberg.org/puregotk/puregotk/v4/gio"
 	"codeberg.org/puregotk/puregotk/v4/gobject"
 	"codeberg.org/puregotk/puregotk/v4/gtk"
-	"github.com/google/go-github/v80/github"
 	"github.com/vinegarhq/vinegar/internal/dirs"
 	"github.com/vinegarhq/vinegar/internal/gutil"
+	"github.com/vinegarhq/vinegar/internal/state"
 )
 
 type manager struct {
@@ -21,6 +21,14 @@ type manager struct {
 
 	builder *gtk.Builder
 	win     adw.ApplicationWindow
+
+	// installed Wine builds, by their index in the picker
+	builds        []string
+	loadingBuilds bool
+
+	snapshotRows []*adw.ActionRow
+	sandboxRows  []*adw.ActionRow
+	pluginRows   []*adw.SwitchRow
 }
 
 func (a *app) newManager() *manager {
@@ -37,6 +45,10 @@ func (a *app) newManager() *manager {
 	cmd.ConnectActivate(&cb)
 
 	m.connectElements()
+	m.connectWineBuilds()
+	m.connectWineRoots()
+	m.loadSnapshots()
+	m.connectPlugins()
 	for name, fn := range map[string]any{
 		"save":  m.saveConfig,
 		"about": m.showAbout,
@@ -52,6 +64,9 @@ func (a *app) newManager() *manager {
 		"delete-studio": m.deleteDeployments,
 		"clear-cache":   m.clearCache,
 		"update":        m.updateWine,
+		"wine-gc":       m.removeUnusedWine,
+		"snapshot":      m.createSnapshot,
+		"plugin-sync":   m.syncPlugins,
 		"restore":       m.boot.restoreSettings,
 
 		"winecfg": func() {
@@ -85,33 +100,38 @@ func (a *app) newManager() *manager {
 		}
 		slog.Info("Fetching releases")
 
-		tag, err := filepath.EvalSymlinks(dirs.WinePath)
+		current, err := filepath.EvalSymlinks(dirs.WinePath)
 		if err == nil {
-			tag, _ = strings.CutPrefix(filepath.Base(tag), dirs.TagPrefix)
-			tags.Append(tag)
+			s, err := state.Load()
+			if err != nil {
+				slog.Error("Failed to load state", "err", err)
+			}
+			current = wineBuildID(s, filepath.Base(current))
+			tags.Append(current)
 			selectedTag.SetSelected(1)
 		}
 
 		m.app.errThread(func() error {
-			client := github.NewClient(nil)
 			ctx := context.Background()
+			var errs []error
 
-			releases, _, err := client.Repositories.ListReleases(
-				ctx, "vinegarhq", "kombucha", nil)
-			if err != nil {
-				return err
-			}
-
-			for _, release := range releases {
-				if *release.TagName == tag {
+			for _, src := range m.cfg.Studio.Sources() {
+				rels, err := m.wineReleases(ctx, src)
+				if err != nil {
+					errs = append(errs, err)
 					continue
 				}
-				gutil.IdleAdd(func() {
-					tags.Append(*release.TagName)
-				})
+
+				for _, rel := range rels {
+					if id := rel.ID(); id != current {
+						gutil.IdleAdd(func() {
+							tags.Append(id)
+						})
+					}
+				}
 			}
 
-			return nil
+			return errors.Join(errs...)
 		})
 	})
 
@@ -129,7 +149,7 @@ func (a *app) newManager() *manager {
 				wineRow.SetSensitive(true)
 				wineRow.Remove(&spin.Widget)
 			})
-			return a.updateWine(sel.GetString())
+			return a.updateWine(context.Background(), sel.GetString())
 		})
 	}
 	updateWine.ConnectClicked(&cnfcb)
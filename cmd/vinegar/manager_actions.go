@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/fs"
@@ -24,7 +25,7 @@ func (m *manager) runWineCmd(e gtk.Entry) {
 		return
 	}
 	m.errThread(func() error {
-		if _, err := m.prepareWine(); err != nil {
+		if _, err := m.prepareWine(context.Background()); err != nil {
 			return err
 		}
 		return m.pfx.Wine(args[0], args[1:]...).Run()
@@ -87,6 +87,10 @@ func (m *manager) connectElements() {
 				slog.Error("FileDialog error", "err", err)
 				return
 			}
+			if err := config.ValidateWineRoot(f.GetPath()); err != nil {
+				m.showError(fmt.Errorf("%s: %w", f.GetPath(), err))
+				return
+			}
 			wine.SetSubtitle(f.GetPath())
 		}
 		win := gtk.WindowNewFromInternalPtr(wine.GetRoot().Ptr)
@@ -122,6 +126,41 @@ func (m *manager) connectElements() {
 		}
 	})
 
+	gamescope := gutil.GetObject[adw.ExpanderRow](b, "gamescope_row")
+	gamescope.SetEnableExpansion(cfg.Gamescope.Enabled)
+	signalSave(&gamescope.Widget, "notify::enable-expansion", func() {
+		cfg.Gamescope.Enabled = gamescope.GetEnableExpansion()
+	})
+	for name, setting := range map[string]*string{
+		"gamescope_output": &cfg.Gamescope.Output,
+		"gamescope_inner":  &cfg.Gamescope.Inner,
+	} {
+		entry := gutil.GetObject[adw.EntryRow](b, name)
+		entry.SetText(*setting)
+		signalSave(&entry.Widget, "apply", func() {
+			res := entry.GetText()
+			if _, _, err := config.ParseResolution(res); res != "" && err != nil {
+				entry.AddCssClass("error")
+				return
+			}
+			entry.RemoveCssClass("error")
+			*setting = res
+		})
+	}
+	refresh := gutil.GetObject[adw.SpinRow](b, "gamescope_refresh")
+	refresh.SetValue(float64(cfg.Gamescope.Refresh))
+	signalSave(&refresh.Widget, "notify::value", func() {
+		cfg.Gamescope.Refresh = int(refresh.GetValue())
+	})
+	// UI model MUST represent the same values by index.
+	upscaler := gutil.GetObject[adw.ComboRow](b, "gamescope_upscaler")
+	upscaler.SetSelected(uint32(max(slices.Index(config.GamescopeUpscalers, cfg.Gamescope.Upscaler), 0)))
+	signalSave(&upscaler.Widget, "notify::selected-item", func() {
+		cfg.Gamescope.Upscaler = config.GamescopeUpscalers[upscaler.GetSelected()]
+	})
+	simpleSwitch("gamescope_hdr", &cfg.Gamescope.HDR)
+	simpleSwitch("gamescope_wayland", &cfg.Gamescope.ExposeWayland)
+
 	card := gutil.GetObject[adw.ComboRow](b, "cards_row")
 	cards := gutil.GetObject[gtk.StringList](b, "cards")
 	values := make(map[string]string, len(sysinfo.Cards))
@@ -139,11 +178,44 @@ func (m *manager) connectElements() {
 	})
 
 	simpleEntry("launcher_row", &cfg.Launcher)
+	m.connectWrappers(signalSave)
+	simpleSwitch("umu_row", &cfg.UMU)
+	m.connectSandbox(signalSave)
 
 	simpleSwitch("discord_row", &cfg.DiscordRPC)
 	simpleSwitch("gamemode_row", &cfg.GameMode)
+
+	timeout := gutil.GetObject[adw.SpinRow](b, "server_timeout_row")
+	timeout.SetValue(float64(cfg.ServerTimeout))
+	signalSave(&timeout.Widget, "notify::value", func() {
+		cfg.ServerTimeout = int(timeout.GetValue())
+	})
+	warmup := gutil.GetObject[adw.SwitchRow](b, "warmup_row")
+	warmup.SetActive(cfg.Warmup)
+	signalSave(&warmup.Widget, "notify::active", func() {
+		cfg.Warmup = warmup.GetActive()
+		enable := cfg.Warmup
+		m.errThread(func() error {
+			if err := m.setAutostart(enable); err != nil {
+				return fmt.Errorf("autostart: %w", err)
+			}
+			return nil
+		})
+	})
 	simpleSwitch("debug_row", &m.cfg.Debug)
 
+	// UI model MUST represent the same values by index.
+	wineDebug := gutil.GetObject[adw.ComboRow](b, "wine_debug_row")
+	if i := slices.Index(config.WineDebugValues, cfg.WineDebug); i >= 0 {
+		wineDebug.SetSelected(uint32(i))
+	} else {
+		wineDebug.SetSelected(uint32(slices.Index(config.WineDebugValues, "default")))
+	}
+	signalSave(&wineDebug.Widget, "notify::selected-item", func() {
+		cfg.WineDebug = config.WineDebugValues[wineDebug.GetSelected()]
+	})
+	simpleEntry("wine_debug_channels_row", &cfg.WineDebugChannels)
+
 	env := gutil.GetObject[adw.ExpanderRow](b, "env_row")
 	for key := range cfg.Env {
 		addKeyRow(&env, cfg.Env, key)
@@ -207,6 +279,8 @@ func (m *manager) connectElements() {
 
 	simpleEntry("version_row", &cfg.ForcedVersion)
 	simpleEntry("channel_row", &cfg.Channel)
+	simpleEntry("mirror_row", &cfg.Mirror)
+	simpleSwitch("prefetch_row", &cfg.Prefetch)
 }
 
 // addKeyRow makes a new custom widget that represents the key value
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+
+	"codeberg.org/puregotk/puregotk/v4/adw"
+	"codeberg.org/puregotk/puregotk/v4/gdk"
+	"codeberg.org/puregotk/puregotk/v4/gio"
+	"codeberg.org/puregotk/puregotk/v4/gtk"
+	"github.com/vinegarhq/vinegar/internal/dirs"
+	"github.com/vinegarhq/vinegar/internal/gutil"
+	"github.com/vinegarhq/vinegar/internal/plugins"
+
+	. "github.com/pojntfx/go-gettext/pkg/i18n"
+)
+
+// connectPlugins binds the installation of plugins from a file chooser,
+// or by dropping files onto the plugins group.
+func (m *manager) connectPlugins() {
+	group := gutil.GetObject[adw.PreferencesGroup](m.builder, "plugins_group")
+
+	folder := gutil.GetObject[adw.EntryRow](m.builder, "plugins_folder_row")
+	folder.SetText(m.cfg.Studio.PluginsFolder)
+	gutil.ConnectSignal(&folder.Widget, "apply", func() {
+		m.cfg.Studio.PluginsFolder = folder.GetText()
+		folder.ActivateActionVariant("win.save", nil)
+	})
+
+	gutil.ConnectBuilderSimple(m.builder, "plugins_add", "clicked", func() {
+		filter := gtk.NewFileFilter()
+		filter.SetName(L("Studio Plugins"))
+		for _, ext := range plugins.Extensions {
+			filter.AddSuffix(ext[1:])
+		}
+
+		dialog := gtk.NewFileDialog()
+		dialog.SetDefaultFilter(filter)
+		var ready gio.AsyncReadyCallback = func(_, resPtr, _ uintptr) {
+			res := gio.SimpleAsyncResultNewFromInternalPtr(resPtr)
+			files, err := dialog.OpenMultipleFinish(res)
+			if err != nil {
+				slog.Error("FileDialog error", "err", err)
+				return
+			}
+			var names []string
+			for i := range files.GetNItems() {
+				var f gio.FileBase
+				f.SetGoPointer(files.GetItem(i))
+				names = append(names, f.GetPath())
+			}
+			m.installPlugins(names)
+		}
+		win := gtk.WindowNewFromInternalPtr(group.GetRoot().Ptr)
+		dialog.OpenMultiple(win, nil, &ready, 0)
+	})
+
+	target := gtk.NewDropTarget(gdk.FileListGLibType(), gdk.ActionCopyValue)
+	drop := func(gtk.DropTarget, uintptr, float64, float64) bool {
+		list := &gutil.Slice[gdk.FileList](target.GetValue().GetBoxed(), 1)[0]
+
+		var names []string
+		for l := list.GetFiles(); l != nil; l = l.Next {
+			var f gio.FileBase
+			f.SetGoPointer(l.Data)
+			names = append(names, f.GetPath())
+		}
+		m.installPlugins(names)
+		return true
+	}
+	target.ConnectDrop(&drop)
+	group.AddController(&target.EventController)
+
+	m.loadPlugins()
+}
+
+func (m *manager) installPlugins(names []string) {
+	var errs []error
+	for _, name := range names {
+		if _, err := plugins.Install(dirs.Plugins, name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		m.showError(err)
+	} else {
+		m.showToast(L("Installed plugins"))
+	}
+	m.loadPlugins()
+}
+
+func (m *manager) syncPlugins() error {
+	if m.cfg.Studio.PluginsFolder == "" {
+		return errors.New(L("No plugins folder is set to sync from"))
+	}
+	if err := m.syncPluginsFolder(); err != nil {
+		return err
+	}
+	gutil.IdleAdd(m.loadPlugins)
+
+	m.showToast(L("Synced plugins"))
+	return nil
+}
+
+// loadPlugins lists the installed plugins, each with a toggle to
+// enable it and an action to remove it.
+func (m *manager) loadPlugins() {
+	group := gutil.GetObject[adw.PreferencesGroup](m.builder, "plugins_group")
+	for _, row := range m.pluginRows {
+		group.Remove(&row.Widget)
+		row.Unref()
+	}
+	m.pluginRows = nil
+
+	list, err := plugins.List(dirs.Plugins)
+	if err != nil {
+		slog.Error("Failed to list plugins", "err", err)
+		return
+	}
+
+	for _, p := range list {
+		row := adw.NewSwitchRow()
+		row.SetTitle(p.Name)
+		row.SetActive(p.Enabled)
+		gutil.ConnectSignal(row, "notify::active", func() {
+			if err := p.SetEnabled(row.GetActive()); err != nil {
+				m.showError(err)
+			}
+		})
+
+		remove := gtk.NewButton()
+		remove.SetValign(gtk.AlignCenterValue)
+		remove.SetIconName("edit-delete-symbolic")
+		remove.SetTooltipText(L("Remove"))
+		remove.AddCssClass("flat")
+		gutil.ConnectSignal(remove, "clicked", func() {
+			if err := p.Remove(); err != nil {
+				m.showError(err)
+				return
+			}
+			m.loadPlugins()
+		})
+
+		row.AddSuffix(&remove.Widget)
+		group.Add(&row.Widget)
+		m.pluginRows = append(m.pluginRows, row)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"log/slog"
+	"slices"
+
+	"codeberg.org/puregotk/puregotk/v4/adw"
+	"codeberg.org/puregotk/puregotk/v4/gio"
+	"codeberg.org/puregotk/puregotk/v4/gtk"
+	"github.com/vinegarhq/vinegar/internal/gutil"
+
+	. "github.com/pojntfx/go-gettext/pkg/i18n"
+)
+
+// connectSandbox binds the sandbox toggle, and the selection
+// of the additional folders the sandbox permits access to.
+func (m *manager) connectSandbox(signalSave func(*gtk.Widget, string, func())) {
+	cfg := &m.cfg.Studio.Sandbox
+	w := gutil.GetObject[adw.ExpanderRow](m.builder, "sandbox_row")
+	w.SetEnableExpansion(cfg.Enabled)
+	signalSave(&w.Widget, "notify::enable-expansion", func() {
+		cfg.Enabled = w.GetEnableExpansion()
+	})
+
+	gutil.ConnectBuilderSimple(m.builder, "sandbox_add", "clicked", func() {
+		dialog := gtk.NewFileDialog()
+		var ready gio.AsyncReadyCallback = func(_, resPtr, _ uintptr) {
+			res := gio.SimpleAsyncResultNewFromInternalPtr(resPtr)
+			f, err := dialog.SelectFolderFinish(res)
+			if err != nil {
+				slog.Error("FileDialog error", "err", err)
+				return
+			}
+			if !slices.Contains(cfg.Paths, f.GetPath()) {
+				cfg.Paths = append(cfg.Paths, f.GetPath())
+			}
+			w.ActivateActionVariant("win.save", nil)
+			m.loadSandboxPaths()
+		}
+		win := gtk.WindowNewFromInternalPtr(w.GetRoot().Ptr)
+		dialog.SelectFolder(win, nil, &ready, 0)
+	})
+
+	m.loadSandboxPaths()
+}
+
+func (m *manager) loadSandboxPaths() {
+	cfg := &m.cfg.Studio.Sandbox
+	w := gutil.GetObject[adw.ExpanderRow](m.builder, "sandbox_row")
+	for _, row := range m.sandboxRows {
+		w.Remove(&row.Widget)
+		row.Unref()
+	}
+	m.sandboxRows = nil
+
+	for _, path := range cfg.Paths {
+		row := adw.NewActionRow()
+		row.SetTitle(path)
+
+		remove := gtk.NewButton()
+		remove.SetValign(gtk.AlignCenterValue)
+		remove.SetIconName("edit-delete-symbolic")
+		remove.SetTooltipText(L("Remove"))
+		remove.AddCssClass("flat")
+		gutil.ConnectSignal(remove, "clicked", func() {
+			cfg.Paths = slices.DeleteFunc(cfg.Paths, func(p string) bool {
+				return p == path
+			})
+			w.ActivateActionVariant("win.save", nil)
+			m.loadSandboxPaths()
+		})
+
+		row.AddSuffix(&remove.Widget)
+		w.AddRow(&row.Widget)
+		m.sandboxRows = append(m.sandboxRows, row)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"log/slog"
+
+	"codeberg.org/puregotk/puregotk/v4/adw"
+	"codeberg.org/puregotk/puregotk/v4/gtk"
+	"github.com/vinegarhq/vinegar/internal/dirs"
+	"github.com/vinegarhq/vinegar/internal/gutil"
+	"github.com/vinegarhq/vinegar/internal/snapshot"
+
+	. "github.com/pojntfx/go-gettext/pkg/i18n"
+)
+
+func (m *manager) createSnapshot() error {
+	if _, err := m.snapshotPrefix("manual"); err != nil {
+		return err
+	}
+	gutil.IdleAdd(m.loadSnapshots)
+
+	m.showToast(L("Created snapshot"))
+	return nil
+}
+
+// loadSnapshots lists the snapshots of the Wineprefix, each with
+// an action to restore or remove it.
+func (m *manager) loadSnapshots() {
+	w := gutil.GetObject[adw.ExpanderRow](m.builder, "snapshots_row")
+	for _, row := range m.snapshotRows {
+		w.Remove(&row.Widget)
+		row.Unref()
+	}
+	m.snapshotRows = nil
+
+	snaps, err := snapshot.List(dirs.Snapshots)
+	if err != nil {
+		slog.Error("Failed to list snapshots", "err", err)
+		return
+	}
+
+	for _, snap := range snaps {
+		row := adw.NewActionRow()
+		row.SetTitle(snap.Time.Local().Format("2006-01-02 15:04:05"))
+		row.SetSubtitle(snap.Reason)
+		if snap.Wine != "" {
+			row.SetSubtitle(snap.Wine + " · " + snap.Reason)
+		}
+
+		restore := gtk.NewButton()
+		restore.SetValign(gtk.AlignCenterValue)
+		restore.SetIconName("edit-undo-symbolic")
+		restore.SetTooltipText(L("Restore"))
+		restore.AddCssClass("flat")
+		gutil.ConnectSignal(restore, "clicked", func() {
+			m.errThread(func() error {
+				if err := m.restoreSnapshot(&snap); err != nil {
+					return err
+				}
+				gutil.IdleAdd(m.loadSnapshots)
+				m.showToast(L("Restored snapshot"))
+				return nil
+			})
+		})
+
+		remove := gtk.NewButton()
+		remove.SetValign(gtk.AlignCenterValue)
+		remove.SetIconName("edit-delete-symbolic")
+		remove.SetTooltipText(L("Remove"))
+		remove.AddCssClass("flat")
+		gutil.ConnectSignal(remove, "clicked", func() {
+			if err := snap.Remove(); err != nil {
+				m.showError(err)
+				return
+			}
+			m.loadSnapshots()
+		})
+
+		row.AddSuffix(&restore.Widget)
+		row.AddSuffix(&remove.Widget)
+		w.AddRow(&row.Widget)
+		m.snapshotRows = append(m.snapshotRows, row)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"log/slog"
+	"slices"
+
+	"codeberg.org/puregotk/puregotk/v4/adw"
+	"codeberg.org/puregotk/puregotk/v4/gtk"
+	"github.com/vinegarhq/vinegar/internal/gutil"
+	"github.com/vinegarhq/vinegar/internal/state"
+	"github.com/vinegarhq/vinegar/internal/wineroot"
+
+	. "github.com/pojntfx/go-gettext/pkg/i18n"
+)
+
+// connectWineBuilds binds the picker of the installed Wine builds,
+// which switches the managed Wine build to the selected build.
+func (m *manager) connectWineBuilds() {
+	row := gutil.GetObject[adw.ComboRow](m.builder, "wine_build_row")
+
+	gutil.ConnectSignal(&row.Widget, "notify::selected", func() {
+		sel := row.GetSelected()
+		if m.loadingBuilds || int(sel) >= len(m.builds) {
+			return
+		}
+		name := m.builds[sel]
+		if name == currentWine() {
+			return
+		}
+		m.errThread(func() error {
+			return m.useWine(name)
+		})
+	})
+
+	gutil.ConnectBuilderSimple(m.builder, "wine_good", "clicked", func() {
+		s, err := state.Load()
+		if err != nil {
+			m.showError(err)
+			return
+		}
+		i := slices.Index(m.builds, s.Wine.Good)
+		if i < 0 {
+			m.showToast(L("No working Wine build is known"))
+			return
+		}
+		row.SetSelected(uint32(i))
+	})
+
+	m.loadWineBuilds()
+}
+
+// loadWineBuilds lists the installed Wine builds in the picker,
+// selecting the build currently in use.
+func (m *manager) loadWineBuilds() {
+	s, err := state.Load()
+	if err != nil {
+		slog.Error("Failed to load state", "err", err)
+	}
+
+	builds, err := wineBuilds(s)
+	if err != nil {
+		slog.Error("Failed to list Wine builds", "err", err)
+	}
+
+	labels := make([]string, len(builds))
+	for i, name := range builds {
+		labels[i] = wineBuildID(s, name)
+		if name == s.Wine.Good {
+			labels[i] += " " + L("(last working)")
+		}
+	}
+
+	list := gutil.GetObject[gtk.StringList](m.builder, "wine_builds")
+	row := gutil.GetObject[adw.ComboRow](m.builder, "wine_build_row")
+
+	m.loadingBuilds = true
+	defer func() { m.loadingBuilds = false }()
+
+	m.builds = builds
+	list.Splice(0, list.GetNItems(), labels)
+	if i := slices.Index(builds, currentWine()); i >= 0 {
+		row.SetSelected(uint32(i))
+	}
+}
+
+func (m *manager) removeUnusedWine() error {
+	if err := m.collectWine(); err != nil {
+		return err
+	}
+	gutil.IdleAdd(m.loadWineBuilds)
+
+	m.showToast(L("Removed unused Wine builds"))
+	return nil
+}
+
+// connectWineRoots binds the list of Wine installations found on
+// the system, selecting one sets it as the wine root.
+func (m *manager) connectWineRoots() {
+	list := gutil.GetObject[gtk.ListBox](m.builder, "wine_roots")
+	popover := gutil.GetObject[gtk.Popover](m.builder, "wine_discover")
+	wine := gutil.GetObject[adw.ActionRow](m.builder, "wine_row")
+
+	gutil.ConnectBuilderSimple(m.builder, "wine_discover", "show", func() {
+		list.RemoveAll()
+		slog.Info("Discovering Wine installations")
+
+		m.errThread(func() error {
+			roots := wineroot.Discover()
+			gutil.IdleAdd(func() {
+				if len(roots) == 0 {
+					row := adw.NewActionRow()
+					row.SetTitle(L("No Wine installations found"))
+					list.Append(&row.Widget)
+					return
+				}
+
+				for _, root := range roots {
+					row := adw.NewActionRow()
+					row.SetTitle(root.Kind)
+					if root.Version != "" {
+						row.SetTitle(root.Kind + " · " + root.Version)
+					}
+					row.SetSubtitle(root.Path)
+					row.SetActivatable(true)
+					activated := func(_ adw.ActionRow) {
+						popover.Popdown()
+						wine.SetSubtitle(root.Path)
+					}
+					row.ConnectActivated(&activated)
+					list.Append(&row.Widget)
+				}
+			})
+			return nil
+		})
+	})
+}
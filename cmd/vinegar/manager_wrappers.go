@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+
+	"codeberg.org/puregotk/puregotk/v4/adw"
+	"codeberg.org/puregotk/puregotk/v4/gtk"
+	"github.com/vinegarhq/vinegar/internal/gutil"
+)
+
+// connectWrappers lists the configured wrappers, each with a toggle
+// to enable it, saved with signalSave as in connectElements. Wrappers
+// are only added in the configuration.
+func (m *manager) connectWrappers(signalSave func(*gtk.Widget, string, func())) {
+	w := gutil.GetObject[adw.ExpanderRow](m.builder, "wrappers_row")
+	wrappers := m.cfg.Studio.Wrappers
+	w.SetSensitive(len(wrappers) > 0)
+
+	for i := range wrappers {
+		wrapper := &wrappers[i]
+		row := adw.NewSwitchRow()
+		row.SetTitle(wrapper.Command)
+		row.SetSubtitle(strings.Join(wrapper.Args, " "))
+		row.SetActive(wrapper.Enabled)
+
+		if _, _, err := wrapper.Path(); err != nil {
+			row.AddCssClass("error")
+			row.SetTooltipText(err.Error())
+		}
+
+		signalSave(&row.Widget, "notify::active", func() {
+			wrapper.Enabled = row.GetActive()
+			if _, _, err := wrapper.Path(); err != nil && wrapper.Enabled {
+				m.showError(err)
+			}
+		})
+		w.AddRow(&row.Widget)
+	}
+}
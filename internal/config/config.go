@@ -10,7 +10,9 @@ import (
 	"os"
 	"os/exec"
 	"path"
+	"path/filepath"
 	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/BurntSushi/toml"
@@ -30,6 +32,13 @@ const (
 	DesktopsResolution = "1814x1024"
 )
 
+// SHA-256 checksums of the release tarballs of the DXVK versions above,
+// which must be updated alongside them.
+const (
+	DXVKSum      = ""
+	DXVKSarekSum = ""
+)
+
 // Order must be the same as the renderer model in the configurator.
 var RendererValues = []string{
 	"D3D11",
@@ -39,23 +48,161 @@ var RendererValues = []string{
 	"Vulkan",
 }
 
+// Order must be the same as the Wine debug model in the configurator.
+var WineDebugValues = []string{
+	"quiet",
+	"default",
+	"graphics",
+	"input",
+	"network",
+	"full",
+}
+
+// WineDebugPresets are the WINEDEBUG channels of each Wine debug preset.
+// Channels enabling trace messages are written to the trace log.
+var WineDebugPresets = map[string]string{
+	"quiet":    "-all",
+	"default":  "fixme-all,err-kerberos,err-ntlm,err-combase",
+	"graphics": "+d3d,+d3d11,+dxgi,+vulkan,+wgl,+opengl",
+	"input":    "+dinput,+xinput,+hid,+keyboard,+cursor,+rawinput",
+	"network":  "+winhttp,+wininet,+winsock,+secur32,+schannel,+dnsapi",
+	"full":     "+all,-relay,-snoop",
+}
+
+// WineSource is a source of Wine builds that can be installed in place
+// of the Kombucha builds. Only one of Repo, URL or Path may be set.
+type WineSource struct {
+	Name string `toml:"name"`
+
+	// GitHub repository in the form of "owner/name", whose release
+	// assets matching the Asset pattern are the builds.
+	Repo  string `toml:"repo,omitempty"`
+	Asset string `toml:"asset,omitempty"`
+
+	// Single build of a remote or local compressed tarball,
+	// with an optional SHA-256 checksum of a remote tarball.
+	URL    string `toml:"url,omitempty"`
+	Sha256 string `toml:"sha256,omitempty"`
+	Path   string `toml:"path,omitempty"`
+}
+
+// KombuchaSource is the default source of Wine builds.
+var KombuchaSource = WineSource{
+	Name:  "kombucha",
+	Repo:  "vinegarhq/kombucha",
+	Asset: "*.tar.xz",
+}
+
+// Order must be the same as the upscaler model in the configurator.
+var GamescopeUpscalers = []string{
+	"",
+	"fsr",
+	"nis",
+	"linear",
+	"nearest",
+	"pixel",
+}
+
+// Gamescope configures running Studio within the gamescope compositor.
+type Gamescope struct {
+	Enabled bool `toml:"enabled"`
+
+	// Resolution of the gamescope window, and the resolution Studio is
+	// rendered at, such as "1920x1080". The output resolution defaults
+	// to the monitor's, and the inner resolution to the virtual desktop's
+	// if any, otherwise to the output's. If both an inner resolution and
+	// a virtual desktop are set, the desktop is resized to the former.
+	Output string `toml:"output"`
+	Inner  string `toml:"inner"`
+	// Refresh rate of the window, defaulting to the monitor's.
+	Refresh int `toml:"refresh"`
+
+	// One of GamescopeUpscalers, gamescope's default if empty.
+	Upscaler      string `toml:"upscaler"`
+	HDR           bool   `toml:"hdr"`
+	ExposeWayland bool   `toml:"expose_wayland"`
+}
+
+// Sandbox configures running Studio within a bubblewrap sandbox, which
+// only has access to Vinegar's data, the user's Documents and Pictures
+// folders and the given paths. The Wineprefix's Documents and Pictures
+// folders are those of the user, where Studio saves places and
+// screenshots. The wineserver is not sandboxed, and the sandbox cannot
+// be used with UMU.
+type Sandbox struct {
+	Enabled bool     `toml:"enabled"`
+	Paths   []string `toml:"paths"`
+}
+
+// Companion is a command that runs alongside Studio, such as rojo,
+// started with the first Studio instance and stopped with the last.
+type Companion struct {
+	// Name shown in the log, the name of the command if empty.
+	Name    string            `toml:"name,omitempty"`
+	Command string            `toml:"command"`
+	Args    []string          `toml:"args,omitempty"`
+	Dir     string            `toml:"dir,omitempty"`
+	Env     map[string]string `toml:"env,omitempty"`
+}
+
+// Wrapper is a command that runs the Wine command, such as gamescope
+// or mangohud. Wrappers are enabled unless set otherwise.
+type Wrapper struct {
+	Command string            `toml:"command"`
+	Args    []string          `toml:"args,omitempty"`
+	Env     map[string]string `toml:"env,omitempty"`
+	Enabled bool              `toml:"enabled"`
+}
+
 type Studio struct {
-	WebView  string `toml:"webview"`
-	WineRoot string `toml:"wineroot"`
+	WebView     string       `toml:"webview"`
+	WineRoot    string       `toml:"wineroot"`
+	WineSources []WineSource `toml:"wine_sources"`
+
+	Renderer  string    `toml:"renderer"`
+	Desktop   string    `toml:"virtual_desktop"`
+	Gamescope Gamescope `toml:"gamescope"`
+	Sandbox   Sandbox   `toml:"sandbox"`
+	ForcedGpu string    `toml:"gpu"`
+
+	// Only adds to Wrappers, reserved for backwards compatibility
+	Launcher string    `toml:"launcher"`
+	Wrappers []Wrapper `toml:"wrappers"`
+
+	Companions []Companion `toml:"companions"`
+
+	UMU        bool `toml:"umu"`
+	DiscordRPC bool `toml:"discord_rpc"`
+	GameMode   bool `toml:"gamemode"`
 
-	Renderer  string `toml:"renderer"`
-	Desktop   string `toml:"virtual_desktop"`
-	ForcedGpu string `toml:"gpu"`
+	// Seconds the wineserver persists after Studio has exited, zero
+	// for Wine's default and indefinitely if negative.
+	ServerTimeout int `toml:"server_timeout"`
+	// Prepare the Wineprefix and start the wineserver at login.
+	Warmup bool `toml:"warmup"`
 
-	Launcher   string `toml:"launcher"`
-	DiscordRPC bool   `toml:"discord_rpc"`
-	GameMode   bool   `toml:"gamemode"`
+	// Name of a WineDebugPresets preset, and additional WINEDEBUG
+	// channels applied after it, such as "+loaddll,-d3d".
+	WineDebug         string `toml:"wine_debug"`
+	WineDebugChannels string `toml:"wine_debug_channels"`
 
 	Env    map[string]string `toml:"env"`
 	FFlags rbxbin.FFlags     `toml:"fflags"`
 
+	// Drive letters mapped to directories in the Wineprefix,
+	// such as "P" to a project directory.
+	Drives map[string]string `toml:"drives"`
+
+	// Directory of plugins installed into Studio on launch.
+	PluginsFolder string `toml:"plugins_folder"`
+
 	ForcedVersion string `toml:"forced_version"`
 	Channel       string `toml:"channel"`
+	Mirror        string `toml:"mirror"`
+
+	// Amount of packages to download at once, unlimited if zero.
+	DownloadConcurrency int  `toml:"download_concurrency"`
+	Prefetch            bool `toml:"prefetch"`
 }
 
 type Config struct {
@@ -63,11 +210,18 @@ type Config struct {
 	// Only adds to Studio.Env, reserved for backwards compatibility
 	Env   map[string]string `toml:"env"`
 	Debug bool              `toml:"debug"`
+
+	// Token used for GitHub API requests, GITHUB_TOKEN if empty.
+	GitHubToken string `toml:"github_token"`
 }
 
 var (
 	ErrWineRootAbs     = errors.New("wine root path is not an absolute path")
 	ErrWineRootInvalid = errors.New("no wine binary present in wine root")
+	ErrWineSource      = errors.New("invalid wine source")
+	ErrWrapper         = errors.New("invalid wrapper")
+	ErrDrive           = errors.New("drive must be a letter other than C and Z")
+	ErrResolution      = errors.New("resolution must be in the form of WIDTHxHEIGHT")
 )
 
 // Load will load the configuration file; if it doesn't exist, it
@@ -107,8 +261,10 @@ func Default() (cfg *Config) {
 			Renderer:   "DXVK",
 			Channel:    "",
 			DiscordRPC: true,
+			WineDebug:  "default",
 			FFlags:     make(rbxbin.FFlags),
 			Env:        make(map[string]string),
+			Drives:     make(map[string]string),
 		},
 	}
 	// No need to select if there is only a single GPU, and to
@@ -154,11 +310,240 @@ func (s *Studio) UnmarshalTOML(data any) error {
 	if !slices.Contains(RendererValues, s.Renderer) {
 		return fmt.Errorf("renderer must be one of %s", RendererValues)
 	}
+	if _, ok := WineDebugPresets[s.WineDebug]; !ok && s.WineDebug != "" {
+		return fmt.Errorf("wine_debug must be one of %s", WineDebugValues)
+	}
+	for _, src := range s.WineSources {
+		if err := src.validate(); err != nil {
+			return err
+		}
+	}
+	for letter, dir := range s.Drives {
+		if _, err := DriveName(letter); err != nil {
+			return err
+		}
+		if !filepath.IsAbs(dir) {
+			return fmt.Errorf("drive %s directory %q must be absolute", letter, dir)
+		}
+	}
+	for _, c := range s.Companions {
+		if c.Command == "" {
+			return errors.New("companion command must be set")
+		}
+		if c.Dir != "" && !filepath.IsAbs(c.Dir) {
+			return fmt.Errorf("companion %s directory must be absolute", c.Command)
+		}
+	}
+	if s.PluginsFolder != "" && !filepath.IsAbs(s.PluginsFolder) {
+		return fmt.Errorf("plugins folder %q must be absolute", s.PluginsFolder)
+	}
+	for _, p := range s.Sandbox.Paths {
+		if !filepath.IsAbs(p) {
+			return fmt.Errorf("sandbox path %q must be absolute", p)
+		}
+	}
+	return s.Gamescope.validate()
+}
+
+func (g *Gamescope) validate() error {
+	for _, res := range []string{g.Output, g.Inner} {
+		if _, _, err := ParseResolution(res); res != "" && err != nil {
+			return fmt.Errorf("gamescope: %w", err)
+		}
+	}
+	if !slices.Contains(GamescopeUpscalers, g.Upscaler) {
+		return fmt.Errorf("gamescope: upscaler must be one of %s", GamescopeUpscalers[1:])
+	}
+	return nil
+}
+
+// DriveName returns the name of the given drive letter in a Wineprefix's
+// dosdevices, such as "p:" for "P" or "P:". The C: and Z: drives are
+// reserved, as they are required by Wine and Vinegar.
+func DriveName(letter string) (string, error) {
+	l := strings.ToLower(strings.TrimSuffix(letter, ":"))
+	if len(l) != 1 || l[0] < 'a' || l[0] > 'z' || l == "c" || l == "z" {
+		return "", fmt.Errorf("%w: %q", ErrDrive, letter)
+	}
+	return l + ":", nil
+}
+
+// ParseResolution returns the width and height of the given
+// resolution, in the form of "1920x1080".
+func ParseResolution(res string) (int, int, error) {
+	ws, hs, _ := strings.Cut(res, "x")
+	w, werr := strconv.Atoi(ws)
+	h, herr := strconv.Atoi(hs)
+	if werr != nil || herr != nil || w <= 0 || h <= 0 {
+		return 0, 0, fmt.Errorf("%w: %q", ErrResolution, res)
+	}
+	return w, h, nil
+}
+
+func (w *Wrapper) UnmarshalTOML(data any) error {
+	type Alias Wrapper
+	proxy := Alias{Enabled: true}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(data); err != nil {
+		return err
+	}
+	if _, err := toml.Decode(buf.String(), &proxy); err != nil {
+		return err
+	}
+
+	*w = Wrapper(proxy)
+	if w.Command == "" {
+		return fmt.Errorf("%w: command must be set", ErrWrapper)
+	}
+	return nil
+}
+
+// Path returns the path to the command of the wrapper, as in [LookPath].
+func (w *Wrapper) Path() (string, bool, error) {
+	p, host, err := LookPath(w.Command)
+	if err != nil {
+		return "", false, fmt.Errorf("%w: %w", ErrWrapper, err)
+	}
+	return p, host, nil
+}
+
+// LookPath searches for the named executable, and when in Flatpak, on the
+// host if it is not present in the sandbox. host reports whether it is only
+// present on the host, where it must be ran with [sysinfo.HostCommand].
+func LookPath(name string) (path string, host bool, err error) {
+	path, err = exec.LookPath(name)
+	if err == nil || !sysinfo.Flatpak || strings.Contains(name, "/") {
+		return path, false, err
+	}
+
+	path, err = sysinfo.HostLookPath(name)
+	if err != nil {
+		return "", false, err
+	}
+	slog.Debug("Found command on host", "name", name, "path", path)
+	return path, true, nil
+}
+
+// Chain returns the enabled wrappers in the order they are to run the
+// Wine command in, preceded by the launcher.
+func (s *Studio) Chain() []Wrapper {
+	var chain []Wrapper
+	if l := strings.Fields(s.Launcher); len(l) > 0 {
+		chain = append(chain, Wrapper{Command: l[0], Args: l[1:], Enabled: true})
+	}
+	for _, w := range s.Wrappers {
+		if w.Enabled {
+			chain = append(chain, w)
+		}
+	}
+	return chain
+}
+
+// CheckWrappers ensures the commands of the enabled wrappers exist.
+func (s *Studio) CheckWrappers() error {
+	for _, w := range s.Chain() {
+		if _, _, err := w.Path(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *WineSource) validate() error {
+	if w.Name == "" || strings.ContainsAny(w.Name, "/"+string(filepath.Separator)) {
+		return fmt.Errorf("%w: name %q must be non-empty without slashes", ErrWineSource, w.Name)
+	}
+	if w.Name == KombuchaSource.Name {
+		return fmt.Errorf("%w: name %q is reserved", ErrWineSource, w.Name)
+	}
+
+	set := 0
+	for _, v := range []string{w.Repo, w.URL, w.Path} {
+		if v != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("%w: %s must set one of repo, url or path", ErrWineSource, w.Name)
+	}
+
+	if w.Path != "" && !filepath.IsAbs(w.Path) {
+		return fmt.Errorf("%w: %s path must be absolute", ErrWineSource, w.Name)
+	}
+	if _, err := path.Match(w.Asset, ""); err != nil {
+		return fmt.Errorf("%w: %s asset: %w", ErrWineSource, w.Name, err)
+	}
+	return nil
+}
+
+// Sources returns the configured Wine sources, preceded by the
+// Kombucha source.
+func (s *Studio) Sources() []WineSource {
+	return append([]WineSource{KombuchaSource}, s.WineSources...)
+}
+
+// ValidateWineRoot ensures the given wine root is an absolute path to a
+// Wine installation. An empty wine root is valid, and represents the
+// Wine installation present in PATH.
+func ValidateWineRoot(root string) error {
+	if root == "" {
+		return nil
+	}
+	if !filepath.IsAbs(root) {
+		return ErrWineRootAbs
+	}
+	if _, ok := ProtonWine(root); ok {
+		return nil
+	}
+	return validateWine(root)
+}
+
+func validateWine(root string) error {
+	info, err := os.Stat(filepath.Join(root, "bin", "wine"))
+	if err != nil || info.IsDir() || info.Mode()&0o111 == 0 {
+		return ErrWineRootInvalid
+	}
 	return nil
 }
 
-func (s *Studio) LauncherPath() (string, error) {
-	return exec.LookPath(strings.Fields(s.Launcher)[0])
+// ProtonWine returns the Wine installation within the given Proton
+// installation, and reports whether root is a Proton installation.
+func ProtonWine(root string) (string, bool) {
+	if _, err := os.Stat(filepath.Join(root, "proton")); err != nil {
+		return "", false
+	}
+	// Older Proton versions use 'dist'.
+	for _, sub := range []string{"files", "dist"} {
+		wine := filepath.Join(root, sub)
+		if validateWine(wine) == nil {
+			return wine, true
+		}
+	}
+	return "", false
+}
+
+// MirrorURL returns the deployment mirror URL to use in place of the
+// Roblox mirror, if any is set. Local directories are represented
+// as file URLs.
+func (s *Studio) MirrorURL() string {
+	m := strings.TrimRight(s.Mirror, "/")
+	if filepath.IsAbs(m) {
+		return "file://" + m
+	}
+	return m
+}
+
+// DXVKChecksum returns the pinned SHA-256 checksum of the release
+// tarball of the given DXVK version, or an empty string if unknown.
+func DXVKChecksum(version string) string {
+	switch version {
+	case DXVKVersion:
+		return DXVKSum
+	case DXVKSarekVersion:
+		return DXVKSarekSum
+	}
+	return ""
 }
 
 func (s *Studio) DXVKVersion() string {
@@ -172,12 +557,18 @@ func (s *Studio) DXVKVersion() string {
 }
 
 func (c *Config) Prefix() *wine.Prefix {
-	pfx := wine.New(
-		path.Join(dirs.Prefixes, "studio"),
-		string(c.Studio.WineRoot),
-	)
+	dir := path.Join(dirs.Prefixes, "studio")
+	root := c.Studio.WineRoot
+	proton, isProton := ProtonWine(root)
+	if isProton {
+		root = proton
+	}
+	pfx := wine.New(dir, root)
 
 	env := maps.Clone(c.Studio.Env)
+	if isProton {
+		protonEnv(env, c.Studio.WineRoot)
+	}
 
 	for _, card := range sysinfo.Cards {
 		if string(c.Studio.ForcedGpu) != card.Addr() {
@@ -208,12 +599,9 @@ func (c *Config) Prefix() *wine.Prefix {
 		break
 	}
 
-	env["WINEDEBUG"] += ",warn+seh" // required to read Roblox logs
+	env["WINEDEBUG"] = c.wineDebug(env["WINEDEBUG"])
 	env["XR_LOADER_DEBUG"] = "none" // already shown in Roblox log
 	env["WINEDLLOVERRIDES"] += ";" + "dxdiagn,winemenubuilder.exe,mscoree,mshtml="
-	if !c.Debug {
-		env["WINEDEBUG"] += ",fixme-all,err-kerberos,err-ntlm,err-combase"
-	}
 
 	env["WEBVIEW2_ADDITIONAL_BROWSER_ARGUMENTS"] = "--disable-gpu"
 
@@ -248,3 +636,54 @@ func (c *Config) Prefix() *wine.Prefix {
 
 	return pfx
 }
+
+// wineDebug returns the WINEDEBUG channels of the debug preset, followed
+// by the given and extra channels. As later channels take precedence, the
+// channel required to read Roblox logs is always last.
+func (c *Config) wineDebug(channels string) string {
+	name := c.Studio.WineDebug
+	if name == "" {
+		name = "default"
+	}
+	preset := WineDebugPresets[name]
+	// Retain full Wine logging in debug mode.
+	if c.Debug && name == "default" {
+		preset = ""
+	}
+
+	var debug []string
+	for _, ch := range []string{preset, channels, c.Studio.WineDebugChannels} {
+		if ch = strings.Trim(ch, ", "); ch != "" {
+			debug = append(debug, ch)
+		}
+	}
+	return strings.Join(append(debug, "warn+seh"), ",")
+}
+
+// protonEnv sets the environment required to run the Wine installation of
+// the given Proton installation directly, normally set by its launch script,
+// and to run it with umu-run. Proton uses the 'pfx' directory of the
+// compatibility data directory [dirs.Proton] as the Wineprefix, which is
+// linked to the Wineprefix used with Wine. Values already present in env
+// are kept.
+func protonEnv(env map[string]string, proton string) {
+	wine, _ := ProtonWine(proton)
+	set := func(k, v string) {
+		if _, ok := env[k]; !ok {
+			env[k] = v
+		}
+	}
+
+	libs := []string{filepath.Join(wine, "lib64"), filepath.Join(wine, "lib")}
+	if p := os.Getenv("LD_LIBRARY_PATH"); p != "" {
+		libs = append(libs, p)
+	}
+	set("LD_LIBRARY_PATH", strings.Join(libs, ":"))
+	set("WINEDLLPATH", filepath.Join(wine, "lib64", "wine")+":"+filepath.Join(wine, "lib", "wine"))
+	set("WINEESYNC", "1")
+	set("WINEFSYNC", "1")
+
+	set("PROTONPATH", proton)
+	set("GAMEID", "umu-default")
+	set("STEAM_COMPAT_DATA_PATH", dirs.Proton)
+}
@@ -0,0 +1,19 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDriveName(t *testing.T) {
+	for letter, want := range map[string]string{"P": "p:", "p:": "p:", "D:": "d:"} {
+		if got, err := DriveName(letter); err != nil || got != want {
+			t.Errorf("%q: expected %q, got %q (%v)", letter, want, got, err)
+		}
+	}
+	for _, letter := range []string{"", "C", "z:", "PP", "1", "P:/"} {
+		if _, err := DriveName(letter); !errors.Is(err, ErrDrive) {
+			t.Errorf("%q: expected ErrDrive, got %v", letter, err)
+		}
+	}
+}
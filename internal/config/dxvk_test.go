@@ -0,0 +1,22 @@
+package config
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestDXVKChecksum(t *testing.T) {
+	for _, version := range []string{DXVKVersion, DXVKSarekVersion} {
+		sum := DXVKChecksum(version)
+		if sum == "" {
+			t.Errorf("%s: expected a pinned checksum", version)
+			continue
+		}
+		if b, err := hex.DecodeString(sum); err != nil || len(b) != 32 {
+			t.Errorf("%s: expected a SHA-256 checksum, got %q", version, sum)
+		}
+	}
+	if sum := DXVKChecksum("0.0"); sum != "" {
+		t.Errorf("expected no checksum for an unknown version, got %q", sum)
+	}
+}
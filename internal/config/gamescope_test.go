@@ -0,0 +1,32 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseResolution(t *testing.T) {
+	if w, h, err := ParseResolution("1920x1080"); err != nil || w != 1920 || h != 1080 {
+		t.Errorf("expected 1920x1080, got %dx%d (%v)", w, h, err)
+	}
+	for _, res := range []string{"", "1920", "1920x", "0x1080", "1920x1080x2", "ax1080"} {
+		if _, _, err := ParseResolution(res); !errors.Is(err, ErrResolution) {
+			t.Errorf("%q: expected ErrResolution, got %v", res, err)
+		}
+	}
+}
+
+func TestGamescopeValidate(t *testing.T) {
+	g := Gamescope{Output: "2560x1440", Upscaler: "fsr"}
+	if err := g.validate(); err != nil {
+		t.Errorf("valid: %v", err)
+	}
+	g.Inner = "720p"
+	if err := g.validate(); !errors.Is(err, ErrResolution) {
+		t.Errorf("inner: expected ErrResolution, got %v", err)
+	}
+	g = Gamescope{Upscaler: "dlss"}
+	if err := g.validate(); err == nil {
+		t.Error("expected invalid upscaler error")
+	}
+}
@@ -0,0 +1,26 @@
+package config
+
+import "testing"
+
+func TestWineDebug(t *testing.T) {
+	for _, tt := range []struct {
+		preset   string
+		debug    bool
+		env      string
+		channels string
+		want     string
+	}{
+		{"default", false, "", "", "fixme-all,err-kerberos,err-ntlm,err-combase,warn+seh"},
+		{"default", true, "", "", "warn+seh"},
+		{"", false, "", "", "fixme-all,err-kerberos,err-ntlm,err-combase,warn+seh"},
+		{"quiet", true, "-seh", "", "-all,-seh,warn+seh"},
+		{"graphics", false, "", " +loaddll,", "+d3d,+d3d11,+dxgi,+vulkan,+wgl,+opengl,+loaddll,warn+seh"},
+	} {
+		c := Config{Debug: tt.debug}
+		c.Studio.WineDebug = tt.preset
+		c.Studio.WineDebugChannels = tt.channels
+		if got := c.wineDebug(tt.env); got != tt.want {
+			t.Errorf("preset %q: expected %q, got %q", tt.preset, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vinegarhq/vinegar/internal/dirs"
+)
+
+func TestValidateWineRoot(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "bin"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ValidateWineRoot(""); err != nil {
+		t.Errorf("empty root: %v", err)
+	}
+	if err := ValidateWineRoot("wine"); !errors.Is(err, ErrWineRootAbs) {
+		t.Errorf("relative root: expected ErrWineRootAbs, got %v", err)
+	}
+	if err := ValidateWineRoot(root); !errors.Is(err, ErrWineRootInvalid) {
+		t.Errorf("missing binary: expected ErrWineRootInvalid, got %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "bin", "wine"), nil, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateWineRoot(root); err != nil {
+		t.Errorf("valid root: %v", err)
+	}
+}
+
+func TestProtonWine(t *testing.T) {
+	root := t.TempDir()
+	wine := filepath.Join(root, "files")
+	if err := os.MkdirAll(filepath.Join(wine, "bin"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(wine, "bin", "wine"), nil, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := ProtonWine(root); ok {
+		t.Error("expected no Proton installation without the proton script")
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "proton"), nil, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := ProtonWine(root); !ok || got != wine {
+		t.Errorf("expected Proton wine %s, got %s", wine, got)
+	}
+	if err := ValidateWineRoot(root); err != nil {
+		t.Errorf("proton root: %v", err)
+	}
+}
+
+func TestProtonEnv(t *testing.T) {
+	env := map[string]string{"GAMEID": "0"}
+	protonEnv(env, "/proton")
+
+	if got := env["STEAM_COMPAT_DATA_PATH"]; got != dirs.Proton {
+		t.Errorf("expected data path of %s, got %s", dirs.Proton, got)
+	}
+	for _, k := range []string{"STEAM_COMPAT_CLIENT_INSTALL_PATH", "STEAM_COMPAT_INSTALL_PATH"} {
+		if _, ok := env[k]; ok {
+			t.Errorf("expected %s to be unset", k)
+		}
+	}
+	if env["GAMEID"] != "0" {
+		t.Errorf("expected set GAMEID to be kept, got %s", env["GAMEID"])
+	}
+	if env["PROTONPATH"] != "/proton" {
+		t.Errorf("expected PROTONPATH of /proton, got %s", env["PROTONPATH"])
+	}
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+)
+
+func TestWrappers(t *testing.T) {
+	var s Studio
+	_, err := toml.Decode(`
+renderer = "DXVK"
+launcher = "nice -n 5"
+
+[[wrappers]]
+command = "gamescope"
+args = ["-f", "--"]
+
+[[wrappers]]
+command = "mangohud"
+enabled = false
+`, &s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Wrapper{
+		{Command: "nice", Args: []string{"-n", "5"}, Enabled: true},
+		{Command: "gamescope", Args: []string{"-f", "--"}, Enabled: true},
+	}
+	if got := s.Chain(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected chain %v, got %v", want, got)
+	}
+
+	if _, err := toml.Decode("renderer = \"DXVK\"\n[[wrappers]]\nargs = [\"-f\"]", &s); err == nil {
+		t.Error("expected wrapper without command to fail")
+	}
+}
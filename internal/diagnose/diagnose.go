@@ -0,0 +1,165 @@
+// Package diagnose implements diagnosis of Wine and Roblox logs, by
+// matching log lines against a table of known signatures.
+package diagnose
+
+import (
+	_ "embed"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+)
+
+//go:embed rules.toml
+var rules string
+
+// Level is the severity of a diagnosis.
+type Level string
+
+const (
+	LevelError   Level = "error"
+	LevelWarning Level = "warning"
+	LevelNoise   Level = "noise"
+)
+
+// Source is the log a line was read from.
+type Source string
+
+const (
+	SourceWine   Source = "wine"
+	SourceRoblox Source = "roblox"
+)
+
+// Rule is a known signature of a log line, and its diagnosis.
+type Rule struct {
+	Name      string `toml:"name"`
+	Source    Source `toml:"source"`
+	Pattern   string `toml:"pattern"`
+	Level     Level  `toml:"level"`
+	Diagnosis string `toml:"diagnosis"`
+	Message   string `toml:"message"`
+	Action    string `toml:"action"`
+
+	re *regexp.Regexp
+}
+
+// Match is a rule matched by a log line.
+type Match struct {
+	*Rule
+
+	// Message is the message of the rule, with its
+	// pattern's submatches expanded.
+	Message string
+	Line    string
+}
+
+// Parse parses the given TOML table of rules.
+func Parse(data string) ([]Rule, error) {
+	var t struct {
+		Rules []Rule `toml:"rule"`
+	}
+	if _, err := toml.Decode(data, &t); err != nil {
+		return nil, err
+	}
+
+	for i := range t.Rules {
+		r := &t.Rules[i]
+		switch r.Level {
+		case LevelError, LevelWarning, LevelNoise:
+		default:
+			return nil, fmt.Errorf("rule %s: invalid level %q", r.Name, r.Level)
+		}
+
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", r.Name, err)
+		}
+		r.re = re
+	}
+
+	return t.Rules, nil
+}
+
+// Rules returns the built-in rules.
+func Rules() []Rule {
+	r, err := Parse(rules)
+	if err != nil {
+		panic("diagnose: " + err.Error())
+	}
+	return r
+}
+
+// Diagnoser matches log lines against rules, keeping track of
+// the rules matched. A Diagnoser is safe for concurrent use.
+type Diagnoser struct {
+	rules     []Rule
+	translate func(string) string
+
+	mu      sync.Mutex
+	matches []Match
+}
+
+// New returns a new Diagnoser using the given rules. The messages of
+// matched rules are translated with translate before their submatches
+// are expanded, if non-nil.
+func New(rules []Rule, translate func(string) string) *Diagnoser {
+	return &Diagnoser{rules: rules, translate: translate}
+}
+
+// Diagnose returns the first rule that matches the given line read
+// from the given source, or nil if none match. first reports whether
+// the rule was matched for the first time by d.
+func (d *Diagnoser) Diagnose(src Source, line string) (m *Match, first bool) {
+	for i := range d.rules {
+		r := &d.rules[i]
+		if r.Source != "" && r.Source != src {
+			continue
+		}
+
+		sub := r.re.FindStringSubmatchIndex(line)
+		if sub == nil {
+			continue
+		}
+
+		msg := r.Message
+		if d.translate != nil && msg != "" {
+			msg = d.translate(msg)
+		}
+		m := Match{
+			Rule:    r,
+			Message: string(r.re.ExpandString(nil, msg, line, sub)),
+			Line:    line,
+		}
+		return &m, d.record(m)
+	}
+	return nil, false
+}
+
+func (d *Diagnoser) record(m Match) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, prev := range d.matches {
+		if prev.Name == m.Name {
+			return false
+		}
+	}
+	d.matches = append(d.matches, m)
+	return true
+}
+
+// Matches returns the first match of every rule matched so far, other
+// than those considered noise.
+func (d *Diagnoser) Matches() []Match {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var matches []Match
+	for _, m := range d.matches {
+		if m.Level != LevelNoise {
+			matches = append(matches, m)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,99 @@
+package diagnose
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRules(t *testing.T) {
+	for _, tt := range []struct {
+		src  Source
+		line string
+		rule string
+		msg  string
+	}{
+		{
+			SourceWine,
+			"0120:err:module:import_dll Library advapi32.dll: to unimplemented function advapi32.dll.SystemFunction036",
+			"corrupt-prefix",
+			"Your Wineprefix is corrupt! Please delete all data in Vinegar's settings.",
+		},
+		{
+			SourceWine,
+			"0024:err:vulkan:vulkan_init_once Failed to load Wine graphics driver supporting Vulkan.",
+			"vulkan-missing", "",
+		},
+		{
+			SourceRoblox,
+			"[FLog::Graphics] vkQueueSubmit failed: VK_ERROR_DEVICE_LOST",
+			"vulkan-error",
+			"The graphics driver failed with VK_ERROR_DEVICE_LOST. Update your graphics driver, or set the renderer to D3D11.",
+		},
+		{
+			SourceWine,
+			"err:   DXVK: No adapters found. Please check your device filter settings and Vulkan setup.",
+			"dxvk-no-adapter", "",
+		},
+		{
+			SourceRoblox,
+			"[FLog::WebView2] WebView2 browser process failed, kind 0",
+			"webview-crash", "",
+		},
+		{SourceWine, "0024:fixme:font:get_name_record_codepage encoding 20 not handled", "font-noise", ""},
+		{SourceWine, "0130:err:kerberos:kerberos_LsaApInitializePackage no Kerberos support, expect problems", "auth-noise", ""},
+		{SourceRoblox, "0130:err:kerberos:kerberos_LsaApInitializePackage", "", ""},
+		{SourceWine, "0024:fixme:ntdll:NtQuerySystemInformation info_class SYSTEM_PERFORMANCE_INFORMATION", "", ""},
+	} {
+		d := New(Rules(), nil)
+		m, first := d.Diagnose(tt.src, tt.line)
+		if tt.rule == "" {
+			if m != nil {
+				t.Errorf("%q: expected no match, got %s", tt.line, m.Name)
+			}
+			continue
+		}
+		if m == nil {
+			t.Errorf("%q: expected %s, got no match", tt.line, tt.rule)
+			continue
+		}
+		if m.Name != tt.rule || !first {
+			t.Errorf("%q: expected first match of %s, got %s (first %v)", tt.line, tt.rule, m.Name, first)
+		}
+		if tt.msg != "" && m.Message != tt.msg {
+			t.Errorf("%q: expected message %q, got %q", tt.line, tt.msg, m.Message)
+		}
+	}
+}
+
+func TestDiagnoserMatches(t *testing.T) {
+	d := New(Rules(), nil)
+	d.Diagnose(SourceWine, "0024:fixme:font:foo")
+	d.Diagnose(SourceRoblox, "VK_ERROR_DEVICE_LOST")
+	if _, first := d.Diagnose(SourceRoblox, "VK_ERROR_OUT_OF_DEVICE_MEMORY"); first {
+		t.Error("expected repeated rule to not be first")
+	}
+
+	matches := d.Matches()
+	if len(matches) != 1 || matches[0].Name != "vulkan-error" {
+		t.Errorf("expected only vulkan-error, got %v", matches)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	if _, err := Parse("[[rule]]\nname = \"x\"\npattern = \"(\"\nlevel = \"error\""); err == nil {
+		t.Error("expected invalid pattern error")
+	}
+	if _, err := Parse("[[rule]]\nname = \"x\"\npattern = \"x\"\nlevel = \"fatal\""); err == nil {
+		t.Error("expected invalid level error")
+	}
+}
+
+func TestDiagnoserTranslate(t *testing.T) {
+	d := New(Rules(), func(msg string) string {
+		return strings.ToUpper(msg[:3]) + msg[3:]
+	})
+	m, _ := d.Diagnose(SourceRoblox, "VK_ERROR_DEVICE_LOST")
+	if m == nil || !strings.HasPrefix(m.Message, "THE graphics driver failed with VK_ERROR_DEVICE_LOST") {
+		t.Errorf("expected translated message with expanded submatch, got %v", m)
+	}
+}
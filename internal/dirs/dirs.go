@@ -15,18 +15,17 @@ var (
 	Downloads = filepath.Join(Cache, "downloads")
 	Logs      = filepath.Join(Cache, "logs")
 	Prefixes  = filepath.Join(Data, "prefixes")
+	Proton    = filepath.Join(Prefixes, "proton")
+	Snapshots = filepath.Join(Data, "snapshots")
 	Versions  = filepath.Join(Data, "versions")
 )
 
-var (
-	TagPrefix = "kombucha-"
-)
-
 var (
 	StatePath   = filepath.Join(Data, "state.json")
 	ConfigPath  = filepath.Join(Config, "config.toml")
 	WinePath    = filepath.Join(Data, "kombucha")
 	AppDataPath = filepath.Join(Data, "appdata")
+	Plugins     = filepath.Join(AppDataPath, "Roblox", "Plugins")
 )
 
 func Windows(name string) string {
@@ -17,6 +17,10 @@ import (
 // Path to the log file that is scoped to the entire program runtime.
 var Path string
 
+// TracePath is the path to the log file of Wine trace messages, which
+// are too verbose for the log file at Path.
+var TracePath string
+
 // Level is a custom type to represent custom log levels with
 // their names.
 type Level int
@@ -27,6 +31,9 @@ var LoggerLevel slog.Level
 const (
 	LevelWine   = Level(slog.LevelInfo + 1)
 	LevelRoblox = Level(slog.LevelInfo + 2)
+
+	// LevelCompanion is the level of the output of Studio's companions.
+	LevelCompanion = Level(slog.LevelInfo + 3)
 )
 
 // Handler is a slog handler with additional extra level types
@@ -39,6 +46,7 @@ type Handler struct {
 func init() {
 	// name-2006-01-02T15:04:05Z07:00.log
 	Path = filepath.Join(dirs.Logs, time.Now().Format(time.RFC3339)+".log")
+	TracePath = filepath.Join(dirs.Logs, "trace", filepath.Base(Path))
 }
 
 // Level implements slog.Leveler.
@@ -59,17 +67,19 @@ func (l Level) String() string {
 		return "WIN"
 	case LevelRoblox:
 		return "RBX"
+	case LevelCompanion:
+		return "CMP"
 	default:
 		return l.Level().String()
 	}
 }
 
-func openPath() (*os.File, error) {
-	if err := os.MkdirAll(filepath.Dir(Path), 0o755); err != nil {
+func openPath(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return nil, err
 	}
 
-	f, err := os.OpenFile(Path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, os.ModePerm)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, os.ModePerm)
 	if err != nil {
 		return nil, err
 	}
@@ -77,8 +87,20 @@ func openPath() (*os.File, error) {
 	return f, nil
 }
 
-func cleanupLogs() error {
-	dir := filepath.Dir(Path)
+// OpenTrace opens the log file at TracePath for writing, removing
+// old trace log files in its directory.
+func OpenTrace() (*os.File, error) {
+	f, err := openPath(TracePath)
+	if err != nil {
+		return nil, err
+	}
+	if err := cleanupLogs(filepath.Dir(TracePath)); err != nil {
+		slog.Error("Failed to clear old trace log files", "err", err)
+	}
+	return f, nil
+}
+
+func cleanupLogs(dir string) error {
 	logs, err := os.ReadDir(dir)
 	if err != nil {
 		return err
@@ -106,7 +128,7 @@ func NewHandler(w io.Writer, level slog.Level) slog.Handler {
 	h := &Handler{Handler: NewTextHandler(w, true)}
 	l := slog.New(h)
 
-	f, err := openPath()
+	f, err := openPath(Path)
 	if err == nil {
 		h.file = NewTextHandler(f, false)
 		l.Info("Logging to file", "path", Path)
@@ -114,7 +136,7 @@ func NewHandler(w io.Writer, level slog.Level) slog.Handler {
 		l.Error("Failed to open log file", "err", err)
 	}
 
-	if err := cleanupLogs(); err != nil {
+	if err := cleanupLogs(filepath.Dir(Path)); err != nil {
 		l.Error("Failed to clear old log files", "err", err)
 	}
 
@@ -176,6 +198,8 @@ func NewTextHandler(w io.Writer, color bool) slog.Handler {
 				return tint.Attr(1, slog.String(a.Key, l.String()))
 			case LevelRoblox:
 				return tint.Attr(6, slog.String(a.Key, l.String()))
+			case LevelCompanion:
+				return tint.Attr(5, slog.String(a.Key, l.String()))
 			}
 			return a
 		},
@@ -0,0 +1,133 @@
+package netutil
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+)
+
+// CacheTransport is an http.RoundTripper that caches the responses of
+// GET requests on disk, revalidating them with their ETag. If the server
+// rate-limits or cannot be reached, the cached response is used instead.
+type CacheTransport struct {
+	// Dir is the directory the responses are cached in.
+	Dir string
+
+	// Transport is the underlying http.RoundTripper to use. If nil,
+	// http.DefaultTransport is used.
+	Transport http.RoundTripper
+}
+
+// NewCacheTransport returns a new CacheTransport caching
+// in dir, using http.DefaultTransport.
+func NewCacheTransport(dir string) *CacheTransport {
+	return &CacheTransport{Dir: dir}
+}
+
+func (t *CacheTransport) transport() http.RoundTripper {
+	if t.Transport != nil {
+		return t.Transport
+	}
+	return http.DefaultTransport
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *CacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return t.transport().RoundTrip(req)
+	}
+
+	name := t.path(req)
+	cached := t.load(name, req)
+
+	if cached != nil {
+		if etag := cached.Header.Get("ETag"); etag != "" {
+			req = req.Clone(req.Context())
+			req.Header.Set("If-None-Match", etag)
+		}
+	}
+
+	resp, err := t.transport().RoundTrip(req)
+	if err != nil {
+		if cached != nil && req.Context().Err() == nil {
+			slog.Warn("Request failed, using cached response", "url", req.URL, "err", err)
+			return cached, nil
+		}
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		resp.Body.Close()
+		return cached, nil
+	case rateLimited(resp) && cached != nil:
+		resp.Body.Close()
+		slog.Warn("Rate limited, using cached response", "url", req.URL)
+		return cached, nil
+	case resp.StatusCode == http.StatusOK && resp.Header.Get("ETag") != "":
+		t.store(name, resp)
+	}
+	if cached != nil {
+		cached.Body.Close()
+	}
+
+	return resp, nil
+}
+
+func rateLimited(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusTooManyRequests ||
+		(resp.StatusCode == http.StatusForbidden &&
+			resp.Header.Get("X-RateLimit-Remaining") == "0")
+}
+
+// path returns the cache file of the request, which depends on its
+// credentials, as responses may differ with them.
+func (t *CacheTransport) path(req *http.Request) string {
+	h := sha256.New()
+	h.Write([]byte(req.URL.String()))
+	h.Write([]byte(req.Header.Get("Authorization")))
+	return filepath.Join(t.Dir, hex.EncodeToString(h.Sum(nil)))
+}
+
+func (t *CacheTransport) load(name string, req *http.Request) *http.Response {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(b)), req)
+	if err != nil {
+		slog.Warn("Removing invalid cached response", "url", req.URL, "err", err)
+		_ = os.Remove(name)
+		return nil
+	}
+	return resp
+}
+
+// store writes the response to the named cache file, replacing the
+// response body with the body read from it.
+func (t *CacheTransport) store(name string, resp *http.Response) {
+	b, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		slog.Warn("Failed to read response for caching", "err", err)
+		return
+	}
+
+	if err := os.MkdirAll(t.Dir, 0o755); err != nil {
+		slog.Warn("Failed to create cache directory", "err", err)
+		return
+	}
+
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		slog.Warn("Failed to cache response", "err", err)
+		return
+	}
+	_ = os.Rename(tmp, name)
+}
@@ -0,0 +1,59 @@
+package netutil
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCacheTransport(t *testing.T) {
+	var requests, limited int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if limited > 0 {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		io.WriteString(w, "release")
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: NewCacheTransport(t.TempDir())}
+	get := func() string {
+		t.Helper()
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status OK, got %s", resp.Status)
+		}
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	if body := get(); body != "release" {
+		t.Errorf("expected initial body, got %q", body)
+	}
+	if body := get(); body != "release" {
+		t.Errorf("expected revalidated body, got %q", body)
+	}
+	limited = 1
+	if body := get(); body != "release" {
+		t.Errorf("expected cached body when rate limited, got %q", body)
+	}
+	if requests != 3 {
+		t.Errorf("expected 3 requests, got %d", requests)
+	}
+}
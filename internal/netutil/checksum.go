@@ -0,0 +1,100 @@
+package netutil
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+)
+
+// ErrChecksum is the error returned when the checksum of
+// downloaded data does not match its expected checksum.
+var ErrChecksum = errors.New("checksum mismatch")
+
+// FileSum returns the hex-encoded SHA-256 checksum of the named file.
+func FileSum(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// VerifyFile ensures the SHA-256 checksum of the named
+// file matches the hex-encoded checksum sum.
+func VerifyFile(name, sum string) error {
+	got, err := FileSum(name)
+	if err != nil {
+		return err
+	}
+	return verify(got, sum)
+}
+
+// DecodeSum returns the given SHA-256 checksum hex-encoded,
+// accepting either hex or base64 encoded checksums. An empty
+// checksum is returned as-is.
+func DecodeSum(sum string) (string, error) {
+	if sum == "" {
+		return "", nil
+	}
+	if b, err := hex.DecodeString(sum); err == nil && len(b) == sha256.Size {
+		return sum, nil
+	}
+	b, err := base64.StdEncoding.DecodeString(sum)
+	if err != nil || len(b) != sha256.Size {
+		return "", fmt.Errorf("invalid sha256 checksum: %s", sum)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func verify(got, want string) error {
+	if !strings.EqualFold(got, want) {
+		return fmt.Errorf("%w: got %s, want %s", ErrChecksum, got, want)
+	}
+	return nil
+}
+
+// checksum wraps a reader, hashing the data read from it for
+// comparison with an expected checksum once fully read.
+type checksum struct {
+	r    io.Reader
+	h    hash.Hash
+	want string
+}
+
+func newChecksum(r io.Reader, sum string) *checksum {
+	c := &checksum{r: r, want: sum}
+	if sum != "" {
+		c.h = sha256.New()
+		c.r = io.TeeReader(r, c.h)
+	}
+	return c
+}
+
+func (c *checksum) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+// Verify reads any remaining data and ensures the checksum
+// of all the data read matches the expected checksum.
+func (c *checksum) Verify() error {
+	if c.h == nil {
+		return nil
+	}
+	if _, err := io.Copy(io.Discard, c.r); err != nil {
+		return err
+	}
+	return verify(hex.EncodeToString(c.h.Sum(nil)), c.want)
+}
@@ -0,0 +1,105 @@
+package netutil
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v80/github"
+)
+
+// SHA-256 checksums of "test" and "vinegar".
+const (
+	testSum    = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	vinegarSum = "1bc690684dc2af6fde7849d9715f499f0c074c19dff137aa047a68c42f488b26"
+)
+
+func TestDecodeSum(t *testing.T) {
+	for _, tt := range []struct {
+		in, want string
+		err      bool
+	}{
+		{"", "", false},
+		{testSum, testSum, false},
+		{"n4bQgYhMfWWaL+qgxVrQFaO/TxsrC4Is0V1sFbDwCgg=", testSum, false},
+		{testSum[:62], "", true},
+		{"not a checksum", "", true},
+	} {
+		got, err := DecodeSum(tt.in)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("DecodeSum(%q) = %q, %v; want %q, error %v",
+				tt.in, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestVerifyFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(name, []byte("test"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifyFile(name, testSum); err != nil {
+		t.Errorf("expected matching checksum, got %v", err)
+	}
+	if err := VerifyFile(name, strings.ToUpper(testSum)); err != nil {
+		t.Errorf("expected checksum to be case insensitive, got %v", err)
+	}
+	if err := VerifyFile(name, vinegarSum); !errors.Is(err, ErrChecksum) {
+		t.Errorf("expected checksum mismatch, got %v", err)
+	}
+	if err := VerifyFile(name+".missing", testSum); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected missing file error, got %v", err)
+	}
+}
+
+func TestAssetSum(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/wine.tar.xz.sha256":
+			w.Write([]byte(testSum + "  wine.tar.xz\n"))
+		case "/bad.tar.xz.sha256":
+			w.Write([]byte("garbage\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	asset := func(name string) *github.ReleaseAsset {
+		return &github.ReleaseAsset{
+			Name:               github.Ptr(name),
+			BrowserDownloadURL: github.Ptr(srv.URL + "/" + name),
+		}
+	}
+	release := &github.RepositoryRelease{Assets: []*github.ReleaseAsset{
+		asset("wine.tar.xz"), asset("wine.tar.xz.sha256"),
+		asset("bad.tar.xz"), asset("bad.tar.xz.sha256"),
+		asset("plain.tar.xz"),
+	}}
+
+	digest := asset("digest.tar.xz")
+	digest.Digest = github.Ptr("sha256:" + vinegarSum)
+
+	for _, tt := range []struct {
+		asset *github.ReleaseAsset
+		want  string
+		err   bool
+	}{
+		{digest, vinegarSum, false},
+		{release.Assets[0], testSum, false},
+		{release.Assets[2], "", true},
+		{release.Assets[4], "", false},
+	} {
+		got, err := AssetSum(context.Background(), release, tt.asset)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("AssetSum(%s) = %q, %v; want %q, error %v",
+				tt.asset.GetName(), got, err, tt.want, tt.err)
+		}
+	}
+}
@@ -2,32 +2,123 @@ package netutil
 
 import (
 	"archive/tar"
+	"compress/gzip"
+	"context"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 
 	"github.com/ulikunitz/xz"
 )
 
-// ExtractURL will decompress the given XZ compressed tarball URL
-// into path.
-func ExtractURL(url string, dir string) error {
-	resp, err := http.Get(url)
+// ExtractURL will decompress the given compressed tarball URL into
+// path, reporting the download to r if non-nil. If sum is non-empty,
+// the tarball must match the hex-encoded SHA-256 checksum sum, which
+// can only be known once fully extracted. Extraction stops when ctx is
+// cancelled or the checksum does not match, leaving the extracted
+// files in place for the caller to remove. The compression of the
+// tarball is determined by the extension of the URL, as in [Decompress].
+func ExtractURL(ctx context.Context, url, dir, sum string, r Reporter) error {
+	if r == nil {
+		r = new(Progress)
+	}
+
+	resp, err := get(ctx, url)
 	if err != nil {
+		r.Start(-1)
+		r.Error(err)
 		return fmt.Errorf("get: %w", err)
 	}
 	defer resp.Body.Close()
 
-	xz, err := xz.NewReader(resp.Body)
+	r.Start(resp.ContentLength)
+	c := newChecksum(io.TeeReader(resp.Body, &reporterWriter{r: r}), sum)
+	err = extract(ctx, url, c, dir)
+	if err == nil {
+		err = c.Verify()
+	}
+	if err != nil {
+		r.Error(err)
+		return err
+	}
+	r.Done()
+	return nil
+}
+
+// ExtractFile will decompress the named local compressed tarball
+// into path. The compression of the tarball is determined by its
+// extension, as in [Decompress].
+func ExtractFile(ctx context.Context, name, dir string) error {
+	f, err := os.Open(name)
 	if err != nil {
-		return fmt.Errorf("xz: %w", err)
+		return err
 	}
-	r := tar.NewReader(xz)
+	defer f.Close()
+
+	return extract(ctx, name, f, dir)
+}
+
+// Decompress returns a reader decompressing src, with the compression
+// determined by the extension of the given name: XZ (.xz, .txz), gzip
+// (.gz, .tgz) or Zstandard (.zst, .tzst). As no Zstandard implementation
+// is available, decompressing Zstandard requires zstd(1). The returned
+// function must be called to release the decompressor once done.
+func Decompress(ctx context.Context, name string, src io.Reader) (io.Reader, func() error, error) {
+	switch filepath.Ext(name) {
+	case ".xz", ".txz":
+		r, err := xz.NewReader(src)
+		if err != nil {
+			return nil, nil, fmt.Errorf("xz: %w", err)
+		}
+		return r, func() error { return nil }, nil
+	case ".gz", ".tgz":
+		r, err := gzip.NewReader(src)
+		if err != nil {
+			return nil, nil, fmt.Errorf("gzip: %w", err)
+		}
+		return r, r.Close, nil
+	case ".zst", ".tzst":
+		cmd := exec.CommandContext(ctx, "zstd", "-dc")
+		cmd.Stdin = src
+		out, err := cmd.StdoutPipe()
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := cmd.Start(); err != nil {
+			return nil, nil, fmt.Errorf("zstd: %w", err)
+		}
+		return out, func() error {
+			// zstd must be able to write all of its output to exit.
+			_, _ = io.Copy(io.Discard, out)
+			if err := cmd.Wait(); err != nil {
+				return fmt.Errorf("zstd: %w", err)
+			}
+			return nil
+		}, nil
+	}
+	return nil, nil, fmt.Errorf("unsupported compression: %s", filepath.Base(name))
+}
+
+func extract(ctx context.Context, name string, src io.Reader, dir string) (err error) {
+	dr, done, err := Decompress(ctx, name, src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if derr := done(); err == nil {
+			err = derr
+		}
+	}()
+	r := tar.NewReader(dr)
 
 	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		h, err := r.Next()
 		if err == io.EOF {
 			break
@@ -2,115 +2,160 @@
 package netutil
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
-	"unsafe"
-
-	"codeberg.org/puregotk/puregotk/v4/glib"
-	"codeberg.org/puregotk/puregotk/v4/gtk"
 )
 
-type progressCounter struct {
-	total   uint64
-	current uint64
-	pbar    *gtk.ProgressBar
-}
-
-func (pc *progressCounter) Write(p []byte) (int, error) {
-	n := len(p)
-	pc.current += uint64(n)
-	return n, nil
-}
-
 // ErrBadStatus is the error returned by Download and Body
 // if the returned HTTP status code is not http.StatusOK.
 var ErrBadStatus = errors.New("bad status")
 
-// DownloadProgress downloads the named url to the named file, using
-// df as the callback for progress. No retry will be checked here.
-func DownloadProgress(url, file string, pbar *gtk.ProgressBar) error {
-	out, err := os.Create(file)
-	if err != nil {
-		return err
+// DownloadProgress downloads the named url to the named file, reporting
+// the transfer to r if non-nil. No retry will be checked here. The named file is
+// removed if the download fails or is cancelled.
+func DownloadProgress(ctx context.Context, url, file string, r Reporter) error {
+	if r == nil {
+		r = new(Progress)
 	}
-	defer out.Close()
 
-	resp, err := http.Get(url)
+	resp, err := get(ctx, url)
 	if err != nil {
+		r.Start(-1)
+		r.Error(err)
 		return err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("%w: %s", ErrBadStatus, resp.Status)
-	}
-
-	pc := &progressCounter{
-		total: uint64(resp.ContentLength),
-		pbar:  pbar,
+	r.Start(resp.ContentLength)
+	if err := copyFile(file, io.TeeReader(resp.Body, &reporterWriter{r: r})); err != nil {
+		r.Error(err)
+		return err
 	}
+	r.Done()
+	return nil
+}
 
-	var idlecb glib.SourceFunc = func(uintptr) bool {
-		pbar.SetFraction(float64(pc.current) / float64(pc.total))
-		return pc.current != pc.total
+// Body returns the body of the named url as a string.
+func Body(ctx context.Context, url string) (string, error) {
+	resp, err := get(ctx, url)
+	if err != nil {
+		return "", err
 	}
-	glib.TimeoutAdd(16, &idlecb, uintptr(unsafe.Pointer(nil)))
+	defer resp.Body.Close()
 
-	_, err = io.Copy(out, io.TeeReader(resp.Body, pc))
+	b, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	return nil
+	return string(b), nil
 }
 
-// Download downloads the named url to the named file. If an error
-// occurs when downloading the file. Download will retry 3 times before
-// returning a final error.
-func Download(url, file string) error {
+// Download downloads the named url to the named file, reporting the
+// transfer to r if non-nil. If an error occurs when downloading the
+// file. Download will retry 3 times before returning a final error. The
+// named file is removed if the download fails or is cancelled.
+func Download(ctx context.Context, url, file string, r Reporter) error {
+	if r == nil {
+		r = new(Progress)
+	}
+
+	// The transfer is only started once, with the size given by the
+	// first response, as r is not informed of the failed attempts.
+	started := false
+	start := func(total int64) {
+		if !started {
+			started = true
+			r.Start(total)
+		}
+	}
+
+	var err error
 	retries := 3
 	for i := 0; i < retries; i++ {
-		err := download(url, file)
+		err = download(ctx, url, file, r, start)
 		if err == nil {
 			break
 		}
 
-		// additional condition for if the error was a file error or status error
-		if _, ok := err.(*os.PathError); err != nil &&
-			(i == retries-1 || ok || errors.Is(err, ErrBadStatus)) {
-			os.Remove(file) // just remove the thing anyway on failure
-			return err
+		// additional condition for if the error was a file error, status error
+		// or cancellation
+		if _, ok := err.(*os.PathError); i == retries-1 || ok ||
+			errors.Is(err, ErrBadStatus) || ctx.Err() != nil {
+			break
 		}
 
 		log.Printf("Download %s failed, retrying...", url)
 	}
 
+	if err != nil {
+		start(-1)
+		r.Error(err)
+		return err
+	}
+	r.Done()
 	return nil
 }
 
-func download(url, file string) error {
-	out, err := os.Create(file)
+// download performs a single attempt of Download, calling start with
+// the size of the transfer before reporting its bytes to r.
+func download(ctx context.Context, url, file string, r Reporter, start func(int64)) error {
+	resp, err := get(ctx, url)
 	if err != nil {
 		return err
 	}
-	defer out.Close()
+	defer resp.Body.Close()
 
-	resp, err := http.Get(url)
-	if err != nil {
+	start(resp.ContentLength)
+	w := &reporterWriter{r: r}
+	if err := copyFile(file, io.TeeReader(resp.Body, w)); err != nil {
+		// Bytes of a failed attempt are no longer processed.
+		r.Bytes(-w.n)
 		return err
 	}
-	defer resp.Body.Close()
+	return nil
+}
+
+// get performs a GET request to the named url, bound to ctx, and
+// ensures the response status is http.StatusOK.
+func get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("%w: %s", ErrBadStatus, resp.Status)
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s", ErrBadStatus, resp.Status)
 	}
 
-	_, err = io.Copy(out, resp.Body)
+	return resp, nil
+}
+
+// copyFile writes r to the named file, removing it if writing
+// is interrupted to not leave a partial file behind.
+func copyFile(file string, r io.Reader) error {
+	out, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, r)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
 	if err != nil {
+		os.Remove(file)
 		return err
 	}
 
@@ -0,0 +1,135 @@
+package netutil
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Reporter receives the progress of transfers. Implementations must be
+// safe for concurrent use, as transfers may happen simultaneously.
+type Reporter interface {
+	// Start is called when a transfer of total bytes begins,
+	// with a total of -1 if the size of the transfer is unknown.
+	Start(total int64)
+
+	// Bytes is called when n bytes of a transfer were processed.
+	// Bytes may be called with a negative value when the bytes
+	// of a failed transfer are to be processed again.
+	Bytes(n int64)
+
+	// Skip is called when n bytes of the expected total no longer need
+	// to be processed, such as data that was already cached.
+	Skip(n int64)
+
+	// Done is called when a transfer has finished.
+	Done()
+
+	// Error is called when a transfer has failed.
+	Error(err error)
+}
+
+// Nested returns a Reporter that only reports the processed bytes of
+// transfers to r, for when the total of all transfers was already given
+// to r by a single call to Start.
+func Nested(r Reporter) Reporter {
+	return nested{r}
+}
+
+type nested struct{ r Reporter }
+
+func (n nested) Start(int64)   {}
+func (n nested) Bytes(b int64) { n.r.Bytes(b) }
+func (n nested) Skip(b int64)  { n.r.Skip(b) }
+func (n nested) Done()         {}
+func (n nested) Error(error)   {}
+
+// Progress is a Reporter that tracks the amount of bytes processed out of
+// an expected total, across one or more transfers. The zero value is ready
+// to use, and Progress is safe for concurrent use.
+type Progress struct {
+	total   atomic.Int64
+	current atomic.Int64
+	start   atomic.Int64 // unix nanoseconds
+}
+
+// Start implements Reporter, adding total to the expected total.
+func (p *Progress) Start(total int64) {
+	p.start.CompareAndSwap(0, time.Now().UnixNano())
+	if total > 0 {
+		p.total.Add(total)
+	}
+}
+
+// Bytes implements Reporter, marking n bytes as processed.
+func (p *Progress) Bytes(n int64) {
+	p.start.CompareAndSwap(0, time.Now().UnixNano())
+	p.current.Add(n)
+}
+
+// Skip implements Reporter, removing n bytes from the expected total,
+// as to not count them toward the rate of processed bytes.
+func (p *Progress) Skip(n int64) {
+	p.total.Add(-n)
+}
+
+// Done implements Reporter.
+func (p *Progress) Done() {}
+
+// Error implements Reporter.
+func (p *Progress) Error(error) {}
+
+// Current returns the amount of bytes processed.
+func (p *Progress) Current() int64 {
+	return p.current.Load()
+}
+
+// Total returns the amount of bytes expected.
+func (p *Progress) Total() int64 {
+	return p.total.Load()
+}
+
+// Fraction returns the fraction of the bytes processed, from 0 to 1.
+func (p *Progress) Fraction() float64 {
+	total := p.Total()
+	if total <= 0 {
+		return 0
+	}
+	return min(float64(p.Current())/float64(total), 1)
+}
+
+// Rate returns the average amount of bytes processed per second.
+func (p *Progress) Rate() float64 {
+	start := p.start.Load()
+	if start == 0 {
+		return 0
+	}
+	elapsed := time.Since(time.Unix(0, start)).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(p.Current()) / elapsed
+}
+
+// ETA returns the estimated time remaining until all expected
+// bytes are processed, or zero if it is unknown.
+func (p *Progress) ETA() time.Duration {
+	rate := p.Rate()
+	left := p.Total() - p.Current()
+	if rate <= 0 || left <= 0 {
+		return 0
+	}
+	return time.Duration(float64(left) / rate * float64(time.Second))
+}
+
+// reporterWriter implements io.Writer, reporting the length
+// of the written bytes to a Reporter.
+type reporterWriter struct {
+	r Reporter
+	n int64
+}
+
+func (w *reporterWriter) Write(b []byte) (int, error) {
+	w.n += int64(len(b))
+	w.r.Bytes(int64(len(b)))
+	return len(b), nil
+}
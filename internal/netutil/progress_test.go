@@ -0,0 +1,176 @@
+package netutil
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recorder is a Reporter that records the events it receives.
+type recorder struct {
+	mu      sync.Mutex
+	starts  []int64
+	bytes   int64
+	skipped int64
+	done    int
+	errs    int
+}
+
+func (r *recorder) Start(total int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.starts = append(r.starts, total)
+}
+
+func (r *recorder) Bytes(n int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bytes += n
+}
+
+func (r *recorder) Skip(n int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.skipped += n
+}
+
+func (r *recorder) Done() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.done++
+}
+
+func (r *recorder) Error(error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errs++
+}
+
+func TestProgress(t *testing.T) {
+	var p Progress
+	if p.Fraction() != 0 || p.Rate() != 0 || p.ETA() != 0 {
+		t.Fatal("expected zero value to report no progress")
+	}
+
+	p.Start(100)
+	p.Start(-1)
+	p.Start(100)
+	p.Bytes(50)
+	p.Skip(100)
+
+	if p.Total() != 100 {
+		t.Errorf("expected total of 100, got %d", p.Total())
+	}
+	if p.Current() != 50 {
+		t.Errorf("expected 50 processed, got %d", p.Current())
+	}
+	if p.Fraction() != 0.5 {
+		t.Errorf("expected fraction of 0.5, got %v", p.Fraction())
+	}
+
+	p.Bytes(100)
+	if p.Fraction() != 1 {
+		t.Errorf("expected fraction to be capped at 1, got %v", p.Fraction())
+	}
+	if p.ETA() != 0 {
+		t.Errorf("expected no ETA once done, got %v", p.ETA())
+	}
+}
+
+func TestNested(t *testing.T) {
+	var r recorder
+	n := Nested(&r)
+	n.Start(10)
+	n.Bytes(4)
+	n.Skip(6)
+	n.Done()
+	n.Error(nil)
+
+	if len(r.starts) != 0 || r.done != 0 || r.errs != 0 {
+		t.Errorf("expected only bytes to be forwarded, got %+v", &r)
+	}
+	if r.bytes != 4 || r.skipped != 6 {
+		t.Errorf("expected 4 bytes and 6 skipped, got %d and %d", r.bytes, r.skipped)
+	}
+}
+
+func TestReporterWriter(t *testing.T) {
+	var r recorder
+	w := &reporterWriter{r: &r}
+	for _, s := range []string{"vine", "gar"} {
+		n, err := w.Write([]byte(s))
+		if err != nil || n != len(s) {
+			t.Fatalf("write %q: got %d, %v", s, n, err)
+		}
+	}
+	if w.n != 7 || r.bytes != 7 {
+		t.Errorf("expected 7 bytes written and reported, got %d and %d", w.n, r.bytes)
+	}
+}
+
+func TestDownloadRetry(t *testing.T) {
+	var attempts int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("Content-Length", "7")
+		if attempts == 1 {
+			// Close the connection early, failing the copy.
+			w.Write([]byte("vin"))
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		w.Write([]byte("vinegar"))
+	}))
+	defer srv.Close()
+
+	var r recorder
+	name := filepath.Join(t.TempDir(), "file")
+	if err := Download(context.Background(), srv.URL, name, &r); err != nil {
+		t.Fatal(err)
+	}
+
+	if attempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", attempts)
+	}
+	if len(r.starts) != 1 || r.starts[0] != 7 {
+		t.Errorf("expected a single start of 7 bytes, got %v", r.starts)
+	}
+	if r.bytes != 7 || r.done != 1 || r.errs != 0 {
+		t.Errorf("expected 7 bytes and a single done, got %+v", &r)
+	}
+}
+
+func TestDownloadError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	var r recorder
+	name := filepath.Join(t.TempDir(), "file")
+	err := Download(context.Background(), srv.URL, name, &r)
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+	if len(r.starts) != 1 || r.errs != 1 || r.done != 0 {
+		t.Errorf("expected a start paired with an error, got %+v", &r)
+	}
+}
+
+func TestNilReporter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("vinegar"))
+	}))
+	defer srv.Close()
+
+	name := filepath.Join(t.TempDir(), "file")
+	if err := DownloadProgress(context.Background(), srv.URL, name, nil); err != nil {
+		t.Fatal(err)
+	}
+	err := ExtractURL(context.Background(), srv.URL+"/file.tar.gz", t.TempDir(), "", nil)
+	if err == nil {
+		t.Fatal("expected invalid archive to fail")
+	}
+}
@@ -0,0 +1,37 @@
+package netutil
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/google/go-github/v80/github"
+)
+
+// AssetSum returns the hex-encoded SHA-256 checksum of the given release
+// asset, from the digest GitHub computes for it, or otherwise from a
+// '.sha256' sidecar asset published alongside it. An empty checksum is
+// returned if neither is available.
+func AssetSum(ctx context.Context, release *github.RepositoryRelease, asset *github.ReleaseAsset) (string, error) {
+	if sum, ok := strings.CutPrefix(asset.GetDigest(), "sha256:"); ok {
+		return sum, nil
+	}
+
+	for _, a := range release.Assets {
+		if a.GetName() != asset.GetName()+".sha256" {
+			continue
+		}
+		body, err := Body(ctx, a.GetBrowserDownloadURL())
+		if err != nil {
+			return "", fmt.Errorf("sidecar: %w", err)
+		}
+		// Formatted as sha256sum(1) output.
+		sum, _, _ := strings.Cut(strings.TrimSpace(body), " ")
+		return DecodeSum(sum)
+	}
+
+	slog.Warn("Release asset has no checksum, skipping verification",
+		"asset", asset.GetName())
+	return "", nil
+}
@@ -0,0 +1,24 @@
+package netutil
+
+import (
+	"net"
+	"net/http"
+)
+
+// ServeDir serves the named directory over HTTP on the loopback
+// interface, returning its URL and a function to stop serving it.
+//
+// This allows local directories, such as a deployment mirror on a
+// network share, to be used in place of a remote URL by clients that
+// only support HTTP, without exposing the rest of the filesystem.
+func ServeDir(dir string) (string, func() error, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", nil, err
+	}
+
+	srv := &http.Server{Handler: http.FileServer(http.Dir(dir))}
+	go srv.Serve(l)
+
+	return "http://" + l.Addr().String(), srv.Close, nil
+}
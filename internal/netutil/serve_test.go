@@ -0,0 +1,34 @@
+package netutil
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestServeDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "versionQTStudio"), []byte("version-1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	url, stop, err := ServeDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	body, err := Body(context.Background(), url+"/versionQTStudio")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != "version-1" {
+		t.Errorf("expected served file, got %q", body)
+	}
+
+	if _, err := Body(context.Background(), url+"/missing"); !errors.Is(err, ErrBadStatus) {
+		t.Errorf("expected bad status for missing file, got %v", err)
+	}
+}
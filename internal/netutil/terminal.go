@@ -0,0 +1,96 @@
+package netutil
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// TerminalReporter is a Reporter that writes the progress of all of
+// its transfers as a single updating line to a terminal.
+type TerminalReporter struct {
+	Progress
+
+	w      io.Writer
+	mu     sync.Mutex
+	active int
+	last   time.Time
+}
+
+// NewTerminalReporter returns a new TerminalReporter writing to w.
+func NewTerminalReporter(w io.Writer) *TerminalReporter {
+	return &TerminalReporter{w: w}
+}
+
+// Start implements Reporter.
+func (t *TerminalReporter) Start(total int64) {
+	t.Progress.Start(total)
+	t.mu.Lock()
+	t.active++
+	t.mu.Unlock()
+}
+
+// Bytes implements Reporter.
+func (t *TerminalReporter) Bytes(n int64) {
+	t.Progress.Bytes(n)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if time.Since(t.last) < 250*time.Millisecond {
+		return
+	}
+	t.last = time.Now()
+	t.print()
+}
+
+// Done implements Reporter.
+func (t *TerminalReporter) Done() {
+	t.finish()
+}
+
+// Error implements Reporter.
+func (t *TerminalReporter) Error(error) {
+	t.finish()
+}
+
+func (t *TerminalReporter) finish() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.active--
+	if t.active > 0 {
+		return
+	}
+	t.print()
+	fmt.Fprintln(t.w)
+}
+
+func (t *TerminalReporter) print() {
+	if t.Total() <= 0 {
+		fmt.Fprintf(t.w, "\r\033[K%s", FormatSize(t.Current()))
+		return
+	}
+
+	fmt.Fprintf(t.w, "\r\033[K%3.0f%% %s / %s (%s/s, %s left)",
+		t.Fraction()*100,
+		FormatSize(t.Current()),
+		FormatSize(t.Total()),
+		FormatSize(int64(t.Rate())),
+		t.ETA().Round(time.Second),
+	)
+}
+
+// FormatSize returns the given amount of bytes in a human-readable
+// form, using SI units.
+func FormatSize(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "kMGTPE"[exp])
+}
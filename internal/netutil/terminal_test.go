@@ -0,0 +1,46 @@
+package netutil
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTerminalReporter(t *testing.T) {
+	var b strings.Builder
+	r := NewTerminalReporter(&b)
+
+	r.Start(2000)
+	r.Start(1000)
+	r.Bytes(1500)
+	r.Done()
+	if strings.HasSuffix(b.String(), "\n") {
+		t.Fatal("expected no newline while a transfer is active")
+	}
+
+	r.Skip(1000)
+	r.Bytes(500)
+	r.Done()
+
+	out := b.String()
+	if !strings.HasSuffix(out, "\n") {
+		t.Fatalf("expected newline once all transfers finished, got %q", out)
+	}
+	last := out[strings.LastIndex(out, "\r"):]
+	if !strings.Contains(last, "100% 2.0 kB / 2.0 kB") {
+		t.Errorf("expected final progress of 2.0 kB, got %q", last)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	for n, want := range map[int64]string{
+		0:             "0 B",
+		999:           "999 B",
+		1000:          "1.0 kB",
+		1500000:       "1.5 MB",
+		3000000000000: "3.0 TB",
+	} {
+		if got := FormatSize(n); got != want {
+			t.Errorf("FormatSize(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+// Package plugins implements management of Roblox Studio's local plugins.
+package plugins
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// disabledExt is the extension added to a plugin to disable it,
+// which Studio will not recognize as a plugin.
+const disabledExt = ".disabled"
+
+// Extensions are the file extensions of plugins supported by Studio.
+var Extensions = []string{".rbxm", ".rbxmx", ".lua"}
+
+var (
+	ErrUnsupported = errors.New("unsupported plugin file, must be one of .rbxm, .rbxmx or .lua")
+	ErrNotFound    = errors.New("plugin not found")
+)
+
+// Plugin is a plugin file in a plugins directory.
+type Plugin struct {
+	// Path is the path of the plugin file, with the
+	// disabled extension if the plugin is disabled.
+	Path string
+
+	// Name is the file name of the plugin.
+	Name    string
+	Enabled bool
+}
+
+// Supported reports whether the named file is a plugin.
+func Supported(name string) bool {
+	return slices.Contains(Extensions, strings.ToLower(filepath.Ext(name)))
+}
+
+// List returns the plugins in the named plugins directory,
+// sorted by name.
+func List(dir string) ([]Plugin, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var plugins []Plugin
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		name, disabled := strings.CutSuffix(e.Name(), disabledExt)
+		if !Supported(name) {
+			continue
+		}
+		plugins = append(plugins, Plugin{
+			Path:    filepath.Join(dir, e.Name()),
+			Name:    name,
+			Enabled: !disabled,
+		})
+	}
+	return plugins, nil
+}
+
+// Find returns the plugin with the given name in the named
+// plugins directory.
+func Find(dir, name string) (*Plugin, error) {
+	plugins, err := List(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range plugins {
+		if p.Name == name {
+			return &p, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+}
+
+// Install copies the named plugin file into the named plugins directory,
+// replacing the plugin of the same name while retaining whether it
+// was disabled.
+func Install(dir, name string) (*Plugin, error) {
+	if !Supported(name) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupported, filepath.Base(name))
+	}
+
+	p := Plugin{
+		Path:    filepath.Join(dir, filepath.Base(name)),
+		Name:    filepath.Base(name),
+		Enabled: true,
+	}
+	if old, err := Find(dir, p.Name); err == nil {
+		p = *old
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	if err := copyFile(name, p.Path); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Sync installs the plugins in the named source directory into the named
+// plugins directory, if they are not present or differ, and removes the
+// plugins it previously installed that are no longer in the source
+// directory. It returns the plugins that were installed and removed.
+func Sync(dir, src string) (installed, removed []Plugin, err error) {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return nil, nil, err
+	}
+	prev, err := readSynced(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		if !e.Type().IsRegular() || !Supported(e.Name()) {
+			continue
+		}
+		names = append(names, e.Name())
+		name := filepath.Join(src, e.Name())
+
+		if old, err := Find(dir, e.Name()); err == nil {
+			same, err := equal(name, old.Path)
+			if err != nil {
+				return installed, removed, err
+			}
+			if same {
+				continue
+			}
+		}
+
+		p, err := Install(dir, name)
+		if err != nil {
+			return installed, removed, err
+		}
+		installed = append(installed, *p)
+	}
+
+	for _, name := range prev {
+		if slices.Contains(names, name) {
+			continue
+		}
+		p, err := Find(dir, name)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		} else if err != nil {
+			return installed, removed, err
+		}
+		if err := p.Remove(); err != nil {
+			return installed, removed, err
+		}
+		removed = append(removed, *p)
+	}
+
+	return installed, removed, writeSynced(dir, names)
+}
+
+// syncedFile is the file of a plugins directory that lists the
+// names of the plugins installed by Sync, one per line.
+const syncedFile = ".synced"
+
+func readSynced(dir string) ([]string, error) {
+	b, err := os.ReadFile(filepath.Join(dir, syncedFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(b)), nil
+}
+
+func writeSynced(dir string, names []string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + "\n")
+	}
+	return os.WriteFile(filepath.Join(dir, syncedFile), []byte(b.String()), 0o644)
+}
+
+// Remove removes the plugin.
+func (p *Plugin) Remove() error {
+	return os.Remove(p.Path)
+}
+
+// SetEnabled enables or disables the plugin, by renaming it.
+func (p *Plugin) SetEnabled(enabled bool) error {
+	if p.Enabled == enabled {
+		return nil
+	}
+
+	path := filepath.Join(filepath.Dir(p.Path), p.Name)
+	if !enabled {
+		path += disabledExt
+	}
+	if err := os.Rename(p.Path, path); err != nil {
+		return err
+	}
+
+	p.Path = path
+	p.Enabled = enabled
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	// Written to a temporary file first, as Studio may
+	// load a plugin while it is being written.
+	out, err := os.CreateTemp(filepath.Dir(dst), ".plugin-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(out.Name())
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(out.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(out.Name(), dst)
+}
+
+func equal(a, b string) (bool, error) {
+	ab, err := os.ReadFile(a)
+	if err != nil {
+		return false, err
+	}
+	bb, err := os.ReadFile(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(ab, bb), nil
+}
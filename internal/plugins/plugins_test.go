@@ -0,0 +1,113 @@
+package plugins
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPlugins(t *testing.T) {
+	dir := t.TempDir()
+	src := t.TempDir()
+	for name, data := range map[string]string{
+		"Foo.rbxm":   "foo",
+		"Bar.lua":    "print('bar')",
+		"readme.txt": "not a plugin",
+	} {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := Install(dir, filepath.Join(src, "readme.txt")); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got %v", err)
+	}
+
+	installed, _, err := Sync(dir, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(installed) != 2 {
+		t.Fatalf("expected 2 installed plugins, got %v", installed)
+	}
+
+	p, err := Find(dir, "Foo.rbxm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetEnabled(false); err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(p.Path) != "Foo.rbxm.disabled" {
+		t.Errorf("expected disabled plugin path, got %s", p.Path)
+	}
+
+	// Unchanged plugins are not installed again.
+	if installed, _, err := Sync(dir, src); err != nil || len(installed) != 0 {
+		t.Errorf("expected no plugins installed, got %v (%v)", installed, err)
+	}
+
+	// Updated plugins remain disabled.
+	if err := os.WriteFile(filepath.Join(src, "Foo.rbxm"), []byte("foo2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if installed, _, err := Sync(dir, src); err != nil || len(installed) != 1 || installed[0].Enabled {
+		t.Errorf("expected disabled Foo.rbxm installed, got %v (%v)", installed, err)
+	}
+
+	if err := p.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	plugins, err := List(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plugins) != 1 || plugins[0].Name != "Bar.lua" || !plugins[0].Enabled {
+		t.Errorf("expected only Bar.lua, got %v", plugins)
+	}
+}
+
+func TestSyncRemoved(t *testing.T) {
+	dir := t.TempDir()
+	src := t.TempDir()
+	for _, name := range []string{"Foo.rbxm", "Bar.lua"} {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Installed by the user, not by Sync.
+	if err := os.WriteFile(filepath.Join(dir, "Baz.lua"), []byte("baz"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := Sync(dir, src); err != nil {
+		t.Fatal(err)
+	}
+	p, err := Find(dir, "Foo.rbxm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetEnabled(false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Remove(filepath.Join(src, "Foo.rbxm")); err != nil {
+		t.Fatal(err)
+	}
+	installed, removed, err := Sync(dir, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(installed) != 0 || len(removed) != 1 || removed[0].Name != "Foo.rbxm" {
+		t.Errorf("expected only Foo.rbxm removed, got %v and %v", installed, removed)
+	}
+
+	plugins, err := List(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plugins) != 2 || plugins[0].Name != "Bar.lua" || plugins[1].Name != "Baz.lua" {
+		t.Errorf("expected Bar.lua and Baz.lua, got %v", plugins)
+	}
+}
@@ -0,0 +1,241 @@
+// Package snapshot implements archiving and restoring of a Wineprefix.
+package snapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vinegarhq/vinegar/internal/netutil"
+)
+
+// Snapshot is an archive of a Wineprefix, with its metadata stored
+// alongside it.
+type Snapshot struct {
+	// Path is the path of the archive.
+	Path string `json:"-"`
+
+	Time time.Time `json:"time"`
+
+	// Wine is the version of Wine that last prepared the Wineprefix.
+	Wine string `json:"wine,omitempty"`
+
+	// Reason is the reason the snapshot was taken,
+	// such as "manual" or "wine-update".
+	Reason string `json:"reason"`
+}
+
+// List returns the snapshots in the named directory, newest first.
+func List(dir string) ([]Snapshot, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var snaps []Snapshot
+	for _, name := range matches {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		s := Snapshot{Path: strings.TrimSuffix(name, ".json") + ".tar.gz"}
+		if err := json.Unmarshal(b, &s); err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(name), err)
+		}
+		if _, err := os.Stat(s.Path); err != nil {
+			continue
+		}
+		snaps = append(snaps, s)
+	}
+
+	slices.SortFunc(snaps, func(a, b Snapshot) int {
+		return b.Time.Compare(a.Time)
+	})
+	return snaps, nil
+}
+
+// Create archives the Wineprefix at pfx into the named directory. Files
+// of the Wineprefix matching any of the exclude patterns, as in
+// [path.Match] against their slash-separated path relative to the
+// Wineprefix, are not archived.
+func Create(dir, pfx, wine, reason string, exclude []string) (*Snapshot, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	s := Snapshot{
+		Time:   time.Now(),
+		Wine:   wine,
+		Reason: reason,
+	}
+	name := filepath.Join(dir, strconv.FormatInt(s.Time.UnixMilli(), 10))
+	s.Path = name + ".tar.gz"
+
+	if err := archive(s.Path, pfx, exclude); err != nil {
+		_ = os.Remove(s.Path)
+		return nil, err
+	}
+
+	b, err := json.MarshalIndent(&s, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(name+".json", b, 0o644); err != nil {
+		_ = os.Remove(s.Path)
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+func archive(name, pfx string, exclude []string) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	zw := gzip.NewWriter(f)
+	tw := tar.NewWriter(zw)
+
+	err = filepath.WalkDir(pfx, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(pfx, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		for _, pattern := range exclude {
+			if ok, _ := path.Match(pattern, rel); !ok {
+				continue
+			}
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		var link string
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		}
+
+		h, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		h.Name = rel
+		if d.IsDir() {
+			h.Name += "/"
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		src, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(tw, src)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Restore replaces the Wineprefix at pfx with the snapshot. The
+// snapshot is extracted alongside the Wineprefix first, so that the
+// Wineprefix is left untouched if extraction fails.
+func (s *Snapshot) Restore(pfx string) error {
+	tmp, err := os.MkdirTemp(filepath.Dir(pfx), ".restore-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := netutil.ExtractFile(context.Background(), s.Path, tmp); err != nil {
+		return fmt.Errorf("extract: %w", err)
+	}
+
+	old := pfx + ".old"
+	if err := os.RemoveAll(old); err != nil {
+		return err
+	}
+	if err := os.Rename(pfx, old); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.Rename(tmp, pfx); err != nil {
+		_ = os.Rename(old, pfx)
+		return err
+	}
+
+	return os.RemoveAll(old)
+}
+
+// Remove removes the snapshot and its metadata.
+func (s *Snapshot) Remove() error {
+	if err := os.Remove(s.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return os.Remove(strings.TrimSuffix(s.Path, ".tar.gz") + ".json")
+}
+
+// Prune removes all but the newest keep snapshots taken
+// for the given reason in the named directory.
+func Prune(dir, reason string, keep int) error {
+	snaps, err := List(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range snaps {
+		if s.Reason != reason {
+			continue
+		}
+		if keep > 0 {
+			keep--
+			continue
+		}
+		if err := s.Remove(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	pfx := filepath.Join(t.TempDir(), "studio")
+	dir := t.TempDir()
+
+	write := func(name, data string) {
+		t.Helper()
+		name = filepath.Join(pfx, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("user.reg", "settings")
+	write("drive_c/cache/large.bin", "reproducible")
+	if err := os.Symlink("/", filepath.Join(pfx, "z:")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Create(dir, pfx, "wine-10.0", "manual", []string{"drive_c/cache"}); err != nil {
+		t.Fatal(err)
+	}
+	write("user.reg", "broken")
+
+	snaps, err := List(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snaps) != 1 || snaps[0].Wine != "wine-10.0" || snaps[0].Reason != "manual" {
+		t.Fatalf("unexpected snapshots: %+v", snaps)
+	}
+	if err := snaps[0].Restore(pfx); err != nil {
+		t.Fatal(err)
+	}
+
+	if b, err := os.ReadFile(filepath.Join(pfx, "user.reg")); err != nil || string(b) != "settings" {
+		t.Errorf("expected restored user.reg, got %q (%v)", b, err)
+	}
+	if _, err := os.Stat(filepath.Join(pfx, "drive_c", "cache")); !os.IsNotExist(err) {
+		t.Errorf("expected excluded directory to be absent, got %v", err)
+	}
+	if link, err := os.Readlink(filepath.Join(pfx, "z:")); err != nil || link != "/" {
+		t.Errorf("expected restored symlink, got %q (%v)", link, err)
+	}
+}
@@ -0,0 +1,131 @@
+// Package state implements the persistent state of Vinegar, which unlike
+// the configuration, is managed by Vinegar itself and not by the user.
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/vinegarhq/vinegar/internal/dirs"
+	"golang.org/x/sys/unix"
+)
+
+type Wine struct {
+	// Name of the Wine build in the data directory that last
+	// ran Studio until it exited successfully.
+	Good string `json:"good,omitempty"`
+
+	// Names of the Wine builds in the data directory
+	// that were installed by Vinegar.
+	Builds []string `json:"builds,omitempty"`
+
+	// IDs of the releases of the Wine builds installed
+	// by Vinegar, by the names of the builds.
+	IDs map[string]string `json:"ids,omitempty"`
+}
+
+type Prefix struct {
+	// Version of Wine that last prepared the Wineprefix.
+	Wine string `json:"wine,omitempty"`
+
+	// Version of Wine that the user was last warned of
+	// being older than the Wine that prepared the Wineprefix.
+	Downgrade string `json:"downgrade,omitempty"`
+
+	// Drives mapped in the Wineprefix by Vinegar, such as "p:".
+	Drives []string `json:"drives,omitempty"`
+}
+
+type Studio struct {
+	// GUID and channel of the installed Studio deployment.
+	GUID    string `json:"guid,omitempty"`
+	Channel string `json:"channel,omitempty"`
+}
+
+type State struct {
+	Wine   Wine   `json:"wine"`
+	Prefix Prefix `json:"prefix"`
+	Studio Studio `json:"studio"`
+}
+
+// Load will load the state file; if it doesn't exist, the
+// empty state is returned.
+func Load() (*State, error) {
+	var s State
+
+	b, err := os.ReadFile(dirs.StatePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return &s, nil
+	} else if err != nil {
+		return &s, err
+	}
+
+	if err := json.Unmarshal(b, &s); err != nil {
+		return &s, err
+	}
+
+	return &s, nil
+}
+
+// Save writes the state to the state file, replacing
+// it atomically to not lose the state if interrupted.
+func (s *State) Save() error {
+	b, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dirs.StatePath), 0o755); err != nil {
+		return err
+	}
+
+	tmp := dirs.StatePath + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, dirs.StatePath)
+}
+
+// Update loads the state, applies fn to it and saves it. The state
+// file is locked meanwhile, for changes made concurrently by other
+// goroutines or processes to not be lost.
+func Update(fn func(*State)) error {
+	unlock, err := lock()
+	if err != nil {
+		return fmt.Errorf("lock: %w", err)
+	}
+	defer unlock()
+
+	s, err := Load()
+	if err != nil {
+		return err
+	}
+	fn(s)
+	return s.Save()
+}
+
+// lock acquires an exclusive lock on the state file's lock file,
+// returning a function to release it.
+func lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(dirs.StatePath), 0o755); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(dirs.StatePath+".lock", os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	// Locks are held by the open file, and as such also
+	// exclude other goroutines of the same process.
+	if err := unix.Flock(int(f.Fd()), unix.LOCK_EX); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return func() { f.Close() }, nil
+}
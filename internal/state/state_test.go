@@ -0,0 +1,39 @@
+package state
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/vinegarhq/vinegar/internal/dirs"
+)
+
+func TestUpdateConcurrent(t *testing.T) {
+	dirs.StatePath = filepath.Join(t.TempDir(), "state.json")
+
+	var wg sync.WaitGroup
+	for i := range 16 {
+		wg.Go(func() {
+			if err := Update(func(s *State) {
+				s.Prefix.Drives = append(s.Prefix.Drives, fmt.Sprintf("%c:", 'd'+i))
+			}); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	wg.Wait()
+
+	s, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Prefix.Drives) != 16 {
+		t.Fatalf("expected 16 drives, got %v", s.Prefix.Drives)
+	}
+	slices.Sort(s.Prefix.Drives)
+	if s.Prefix.Drives[0] != "d:" || s.Prefix.Drives[15] != "s:" {
+		t.Errorf("unexpected drives %v", s.Prefix.Drives)
+	}
+}
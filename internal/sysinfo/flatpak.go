@@ -0,0 +1,109 @@
+package sysinfo
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ErrHostAccess is returned when the Flatpak sandbox is not permitted
+// to run commands on the host.
+var ErrHostAccess = errors.New("Flatpak sandbox is not permitted to run host commands")
+
+// Path of flatpak-spawn within the Flatpak runtime.
+const flatpakSpawn = "/usr/bin/flatpak-spawn"
+
+// HostAccess ensures the Flatpak sandbox is permitted to talk to the
+// Flatpak session helper, required to run commands on the host.
+func HostAccess() error {
+	f, err := os.Open("/.flatpak-info")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return hostAccess(f)
+}
+
+// hostAccess implements HostAccess for the given Flatpak metadata.
+func hostAccess(r io.Reader) error {
+	section := ""
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if strings.HasPrefix(line, "[") {
+			section = line
+			continue
+		}
+		name, policy, _ := strings.Cut(line, "=")
+		if section == "[Session Bus Policy]" &&
+			name == "org.freedesktop.Flatpak" &&
+			(policy == "talk" || policy == "own") {
+			return nil
+		}
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+
+	id := os.Getenv("FLATPAK_ID")
+	if id == "" {
+		id = "org.vinegarhq.Vinegar"
+	}
+	return fmt.Errorf("%w, allow it with: flatpak override --user --talk-name=org.freedesktop.Flatpak %s",
+		ErrHostAccess, id)
+}
+
+// HostLookPath searches for the named executable on the host,
+// outside of the Flatpak sandbox.
+func HostLookPath(name string) (string, error) {
+	if err := HostAccess(); err != nil {
+		return "", err
+	}
+
+	out, err := exec.Command(flatpakSpawn, "--host",
+		"sh", "-c", `command -v -- "$1"`, "sh", name).Output()
+	p := string(bytes.TrimSpace(out))
+	if err != nil || !strings.HasPrefix(p, "/") {
+		return "", &exec.Error{Name: name, Err: exec.ErrNotFound}
+	}
+	return p, nil
+}
+
+// HostCommand returns the command that runs the command following it on
+// the host, with the given additional environment variables.
+func HostCommand(env []string) []string {
+	cmd := []string{flatpakSpawn, "--host", "--watch-bus"}
+	for _, e := range env {
+		cmd = append(cmd, "--env="+e)
+	}
+	return cmd
+}
+
+// HostEnv returns the variables of env that are not inherited from the
+// environment of Vinegar, which must be given to HostCommand.
+func HostEnv(env []string) []string {
+	inherited := os.Environ()
+	var vars []string
+	for _, v := range env {
+		if !slices.Contains(inherited, v) {
+			vars = append(vars, v)
+		}
+	}
+	return vars
+}
+
+// HostVisible reports whether the named path within the Flatpak sandbox
+// refers to the same file on the host. Only the user's home directory,
+// where Flatpak applications keep their data, is shared with the host.
+func HostVisible(name string) bool {
+	home, err := os.UserHomeDir()
+	return err == nil && strings.HasPrefix(filepath.Clean(name), home+"/")
+}
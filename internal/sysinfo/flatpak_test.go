@@ -0,0 +1,56 @@
+package sysinfo
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestHostAccess(t *testing.T) {
+	for _, tt := range []struct {
+		info string
+		err  error
+	}{
+		{"[Session Bus Policy]\norg.freedesktop.Flatpak=talk\n", nil},
+		{"[Session Bus Policy]\norg.freedesktop.Flatpak=own\n", nil},
+		{"[Session Bus Policy]\norg.freedesktop.Flatpak=see\n", ErrHostAccess},
+		{"[System Bus Policy]\norg.freedesktop.Flatpak=talk\n", ErrHostAccess},
+		{"[Application]\nname=org.vinegarhq.Vinegar\n", ErrHostAccess},
+	} {
+		err := hostAccess(strings.NewReader(tt.info))
+		if !errors.Is(err, tt.err) {
+			t.Errorf("hostAccess(%q) = %v, want %v", tt.info, err, tt.err)
+		}
+	}
+}
+
+func TestHostEnv(t *testing.T) {
+	t.Setenv("VINEGAR_TEST", "inherited")
+
+	env := []string{"VINEGAR_TEST=inherited", "DXVK_HUD=fps", "VINEGAR_TEST=changed"}
+	want := []string{"DXVK_HUD=fps", "VINEGAR_TEST=changed"}
+	if got := HostEnv(env); !slices.Equal(got, want) {
+		t.Errorf("HostEnv() = %v, want %v", got, want)
+	}
+}
+
+func TestHostVisible(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	for _, tt := range []struct {
+		name string
+		want bool
+	}{
+		{filepath.Join(home, ".var/app/org.vinegarhq.Vinegar/data/wine"), true},
+		{home + "/../etc", false},
+		{home, false},
+		{"/app/bin/wine", false},
+	} {
+		if got := HostVisible(tt.name); got != tt.want {
+			t.Errorf("HostVisible(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+// Package wineroot discovers Wine installations present on the system.
+package wineroot
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/vinegarhq/vinegar/internal/config"
+)
+
+// Root is a Wine installation found on the system.
+type Root struct {
+	// Path is the wine root, usable as the wine root in
+	// the configuration.
+	Path string
+
+	// Kind describes where the installation was found,
+	// such as "System", "Lutris" or "Proton".
+	Kind string
+
+	// Version is the version reported by the installation's
+	// Wine binary, or empty if it could not be determined.
+	Version string
+}
+
+// Discover returns the valid Wine installations found in common
+// locations, alongside their versions.
+func Discover() []Root {
+	var roots []Root
+	add := func(kind string, paths ...string) {
+		for _, path := range paths {
+			if config.ValidateWineRoot(path) != nil {
+				continue
+			}
+			if slices.ContainsFunc(roots, func(r Root) bool { return r.Path == path }) {
+				continue
+			}
+			roots = append(roots, Root{Path: path, Kind: kind})
+		}
+	}
+
+	if wine, err := exec.LookPath("wine"); err == nil {
+		if wine, err := filepath.EvalSymlinks(wine); err == nil {
+			add("System", filepath.Dir(filepath.Dir(wine)))
+		}
+	}
+
+	add("System", glob("/opt/wine-*")...)
+
+	flatpak := filepath.Join(xdg.Home, ".var", "app")
+	add("Lutris", glob(
+		filepath.Join(xdg.DataHome, "lutris", "runners", "wine", "*"),
+		filepath.Join(flatpak, "net.lutris.Lutris", "data", "lutris", "runners", "wine", "*"),
+	)...)
+	add("Bottles", glob(
+		filepath.Join(xdg.DataHome, "bottles", "runners", "*"),
+		filepath.Join(flatpak, "com.usebottles.bottles", "data", "bottles", "runners", "*"),
+	)...)
+
+	for _, steam := range steamRoots() {
+		add("Steam", glob(
+			filepath.Join(steam, "compatibilitytools.d", "*"))...)
+
+		for _, lib := range steamLibraries(steam) {
+			add("Proton", glob(
+				filepath.Join(lib, "steamapps", "common", "Proton*"))...)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := range roots {
+		wg.Go(func() {
+			roots[i].Version, _ = Version(roots[i].Path)
+		})
+	}
+	wg.Wait()
+
+	return roots
+}
+
+// Version returns the version reported by the Wine binary
+// of the given wine root, or of the Proton installation.
+func Version(root string) (string, error) {
+	if wine, ok := config.ProtonWine(root); ok {
+		// Formatted as "<timestamp> <version>".
+		if b, err := os.ReadFile(filepath.Join(root, "version")); err == nil {
+			fields := strings.Fields(string(b))
+			if len(fields) > 0 {
+				return fields[len(fields)-1], nil
+			}
+		}
+		root = wine
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx,
+		filepath.Join(root, "bin", "wine"), "--version").Output()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+func steamRoots() []string {
+	var roots []string
+	for _, path := range []string{
+		filepath.Join(xdg.Home, ".steam", "root"),
+		filepath.Join(xdg.Home, ".steam", "steam"),
+		filepath.Join(xdg.DataHome, "Steam"),
+		filepath.Join(xdg.Home, ".var", "app", "com.valvesoftware.Steam", ".local", "share", "Steam"),
+	} {
+		path, err := filepath.EvalSymlinks(path)
+		if err != nil || slices.Contains(roots, path) {
+			continue
+		}
+		roots = append(roots, path)
+	}
+	return roots
+}
+
+// steamLibraries returns the library folders of the given Steam
+// installation, which always includes the installation itself.
+func steamLibraries(steam string) []string {
+	libs := []string{steam}
+
+	f, err := os.Open(filepath.Join(steam, "steamapps", "libraryfolders.vdf"))
+	if err != nil {
+		return libs
+	}
+	defer f.Close()
+
+	// Only the library paths are of interest, which are each
+	// on their own line: "path"		"/path/to/library"
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		rest, ok := strings.CutPrefix(strings.TrimSpace(s.Text()), `"path"`)
+		if !ok {
+			continue
+		}
+		lib := strings.Trim(strings.TrimSpace(rest), `"`)
+		if lib != steam && !slices.Contains(libs, lib) {
+			libs = append(libs, lib)
+		}
+	}
+
+	return libs
+}
+
+func glob(patterns ...string) []string {
+	var matches []string
+	for _, pattern := range patterns {
+		m, _ := filepath.Glob(pattern)
+		matches = append(matches, m...)
+	}
+	return matches
+}
+
+// CompareVersions compares the given versions as reported by Wine,
+// such as "wine-10.0 (Staging)", returning -1, 0 or +1 if a is older,
+// the same or newer than b. Versions are compared by their numeric
+// components, ignoring any suffix.
+func CompareVersions(a, b string) int {
+	va, vb := versionParts(a), versionParts(b)
+	for i := range max(len(va), len(vb)) {
+		var x, y int
+		if i < len(va) {
+			x = va[i]
+		}
+		if i < len(vb) {
+			y = vb[i]
+		}
+		if x != y {
+			return cmp.Compare(x, y)
+		}
+	}
+	return 0
+}
+
+func versionParts(v string) []int {
+	v = strings.TrimPrefix(v, "wine-")
+	if f := strings.Fields(v); len(f) > 0 {
+		v = f[0]
+	}
+
+	var parts []int
+	for p := range strings.FieldsFuncSeq(v, func(r rune) bool {
+		return r == '.' || r == '-'
+	}) {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+	}
+	return parts
+}
@@ -0,0 +1,21 @@
+package wineroot
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	for _, tt := range []struct {
+		a, b string
+		want int
+	}{
+		{"wine-10.0", "wine-10.0", 0},
+		{"wine-9.22 (Staging)", "wine-10.0", -1},
+		{"wine-10.12", "wine-10.2", 1},
+		{"wine-10.0-rc1", "wine-10.0", 0},
+		{"wine-10.0.1", "wine-10.0", 1},
+		{"", "wine-9.0", -1},
+	} {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}